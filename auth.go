@@ -0,0 +1,230 @@
+package cuttle
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNoCredentials is returned by authenticators when the request has no credentials for their scheme
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned when the credentials are present but not valid, the reason is wrapped
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// context key of the authenticated principal, requests are authenticated once
+const principalKey = "cuttle.principal"
+
+// Principal is the authenticated caller, bound to fields of type cuttle.Principal or *cuttle.Principal.
+// Principal fields fail the request with a 401 when it isn't authenticated, *Principal fields are nil when the
+// request has no credentials at all.
+type Principal struct {
+	// Subject identifies the caller > the sub claim of a jwt or the basic auth user
+	Subject string `json:"subject"`
+	// Scheme that authenticated the request > Bearer, Basic
+	Scheme string `json:"scheme"`
+	// Claims of the token, numbers are json.Number
+	Claims map[string]interface{} `json:"claims,omitempty"`
+	// Scopes granted to the caller, checked against the route's Scopes markers
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+var principalType = reflect.TypeOf(Principal{})
+
+// Authenticator returns the principal of a request, ErrNoCredentials when the request has no credentials it
+// understands and an error wrapping ErrInvalidCredentials when they're wrong. A nil principal is taken as
+// ErrNoCredentials, any other error is a 500.
+type Authenticator interface {
+	Authenticate(ctx Context) (*Principal, error)
+}
+
+// challenger is implemented by authenticators that set the WWW-Authenticate header on 401s
+type challenger interface {
+	Challenge() string
+}
+
+// Authenticators tries each authenticator in order, the first one that finds credentials decides
+//
+//	r.Authenticator = cuttle.Authenticators{&cuttle.JWTAuthenticator{Secret: key}, &cuttle.BasicAuthenticator{Validate: check}}
+type Authenticators []Authenticator
+
+func (a Authenticators) Authenticate(ctx Context) (*Principal, error) {
+	for _, authenticator := range a {
+		principal, err := authenticator.Authenticate(ctx)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return principal, err
+	}
+	return nil, ErrNoCredentials
+}
+
+func (a Authenticators) Challenge() string {
+	var challenges []string
+	for _, authenticator := range a {
+		if c, ok := authenticator.(challenger); ok {
+			challenges = append(challenges, c.Challenge())
+		}
+	}
+	return strings.Join(challenges, ", ")
+}
+
+// BasicAuthenticator checks basic auth credentials with Validate
+type BasicAuthenticator struct {
+	// Realm sent in the WWW-Authenticate header, defaults to Restricted
+	Realm    string
+	Validate func(ctx Context, user, password string) (bool, error)
+}
+
+func (b *BasicAuthenticator) Authenticate(ctx Context) (*Principal, error) {
+	credentials, ok := authorization(ctx, "Basic")
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	decoded, err := base64.StdEncoding.DecodeString(credentials)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	i := strings.IndexByte(string(decoded), ':')
+	if i < 0 {
+		return nil, fmt.Errorf("%w: malformed basic credentials", ErrInvalidCredentials)
+	}
+	user, password := string(decoded[:i]), string(decoded[i+1:])
+	valid, err := b.Validate(ctx, user, password)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, fmt.Errorf("%w: wrong user or password", ErrInvalidCredentials)
+	}
+	return &Principal{Subject: user, Scheme: "Basic"}, nil
+}
+
+func (b *BasicAuthenticator) Challenge() string {
+	realm := b.Realm
+	if realm == "" {
+		realm = "Restricted"
+	}
+	return fmt.Sprintf("Basic realm=%v", strconv.Quote(realm))
+}
+
+// authorization returns the credentials of the Authorization header if it uses scheme
+func authorization(ctx Context, scheme string) (string, bool) {
+	header := ctx.Request().Header.Get(echo.HeaderAuthorization)
+	if len(header) <= len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) || header[len(scheme)] != ' ' {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(scheme)+1:]), true
+}
+
+// authenticate returns the principal of the request, authenticating it on the first call. Failures are returned
+// as 401s, a nil principal means the request has no credentials and optional is set.
+func (r *Cuttle) authenticate(ctx Context, optional bool) (*Principal, error) {
+	if principal, ok := ctx.Get(principalKey).(*Principal); ok {
+		return principal, nil
+	}
+	principal, err := r.Authenticator.Authenticate(ctx)
+	// authenticators returning nothing found no credentials
+	if err == nil && principal == nil {
+		err = ErrNoCredentials
+	}
+	if err == nil {
+		ctx.Set(principalKey, principal)
+		return principal, nil
+	}
+	if optional && errors.Is(err, ErrNoCredentials) {
+		return nil, nil
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return nil, err
+	}
+	if !errors.Is(err, ErrNoCredentials) && !errors.Is(err, ErrInvalidCredentials) {
+		// the authenticator itself failed, its error isn't shown to the client
+		return nil, echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	if c, ok := r.Authenticator.(challenger); ok {
+		ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, c.Challenge())
+	}
+	return nil, echo.NewHTTPError(http.StatusUnauthorized).SetInternal(err)
+}
+
+// principalBinder binds Principal and *Principal fields
+func (r *Cuttle) principalBinder(ptr bool) fieldBinder {
+	return func(ctx Context, field reflect.Value) error {
+		principal, err := r.authenticate(ctx, ptr)
+		if err != nil || principal == nil {
+			return err
+		}
+		if ptr {
+			field.Set(reflect.ValueOf(principal))
+		} else {
+			field.Set(reflect.ValueOf(*principal))
+		}
+		return nil
+	}
+}
+
+// claimsBinder authenticates the request before bind reads the claim, []string fields get array claims
+func (r *Cuttle) claimsBinder(t reflect.Type, name string, bind fieldBinder) fieldBinder {
+	if t == reflect.TypeOf([]string(nil)) {
+		key := newLookupKey(name)
+		bind = func(ctx Context, field reflect.Value) error {
+			principal, _ := ctx.Get(principalKey).(*Principal)
+			values, ok := principal.Claims[key.name].([]interface{})
+			if !ok {
+				values, _ = principal.Claims[key.lower].([]interface{})
+			}
+			claims := make([]string, 0, len(values))
+			for _, v := range values {
+				claims = append(claims, claimString(v))
+			}
+			field.Set(reflect.ValueOf(claims))
+			return nil
+		}
+	}
+	if bind == nil {
+		return nil
+	}
+	return func(ctx Context, field reflect.Value) error {
+		if _, err := r.authenticate(ctx, false); err != nil {
+			return err
+		}
+		return bind(ctx, field)
+	}
+}
+
+// claimString formats a claim for the scalar binders
+func claimString(claim interface{}) string {
+	switch v := claim.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	b, _ := json.Marshal(claim)
+	return string(b)
+}
+
+// claimsResolver reads the claims of the authenticated principal, the request has to be authenticated first
+func claimsResolver(name string, ctx Context) string {
+	principal, ok := ctx.Get(principalKey).(*Principal)
+	if !ok {
+		return ""
+	}
+	return claimString(principal.Claims[name])
+}
@@ -0,0 +1,221 @@
+package cuttle
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("cuttle")
+
+func signToken(t *testing.T, alg string, claims map[string]interface{}, key interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	assert.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	assert.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		hash := sha256.Sum256([]byte(signed))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, hash[:])
+		assert.NoError(t, err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func authRouter(authenticator Authenticator) *Cuttle {
+	r := New()
+	r.Authenticator = authenticator
+	r.GET("/me", func(p struct {
+		User  Principal
+		OrgID uint     `bind:"claims" as:"org_id"`
+		Roles []string `bind:"claims"`
+		Ctx   Context
+	}) error {
+		return p.Ctx.JSON(http.StatusOK, map[string]interface{}{"sub": p.User.Subject, "org": p.OrgID, "roles": p.Roles})
+	})
+	r.GET("/optional", func(p struct {
+		User *Principal
+		Ctx  Context
+	}) error {
+		if p.User == nil {
+			return p.Ctx.String(http.StatusOK, "anonymous")
+		}
+		return p.Ctx.String(http.StatusOK, p.User.Subject)
+	})
+	return r
+}
+
+func TestAuth_JWT(t *testing.T) {
+	r := authRouter(&JWTAuthenticator{Secret: testSecret, Issuer: "cuttle"})
+	exp := time.Now().Add(time.Hour).Unix()
+	valid := signToken(t, "HS256", map[string]interface{}{"sub": "joe", "iss": "cuttle", "exp": exp, "org_id": 12, "roles": []string{"admin", "dev"}}, testSecret)
+
+	for name, tc := range map[string]struct {
+		path, authorization string
+		code                int
+		expect              string
+	}{
+		"valid":          {"/me", "Bearer " + valid, 200, `{"sub":"joe","org":12,"roles":["admin","dev"]}`},
+		"scheme case":    {"/me", "bearer " + valid, 200, ""},
+		"no credentials": {"/me", "", 401, `{"message":"Unauthorized","request_id":"auth"}`},
+		"expired":        {"/me", "Bearer " + signToken(t, "HS256", map[string]interface{}{"sub": "joe", "iss": "cuttle", "exp": time.Now().Add(-time.Hour).Unix()}, testSecret), 401, ""},
+		"wrong issuer":   {"/me", "Bearer " + signToken(t, "HS256", map[string]interface{}{"sub": "joe", "iss": "other"}, testSecret), 401, ""},
+		"wrong secret":   {"/me", "Bearer " + signToken(t, "HS256", map[string]interface{}{"sub": "joe", "iss": "cuttle"}, []byte("other")), 401, ""},
+		"alg none":       {"/me", "Bearer " + signToken(t, "none", map[string]interface{}{"sub": "joe", "iss": "cuttle"}, nil), 401, ""},
+		"malformed":      {"/me", "Bearer abc", 401, ""},
+		"optional":       {"/optional", "", 200, "anonymous"},
+		"optional valid": {"/optional", "Bearer " + valid, 200, "joe"},
+		"optional wrong": {"/optional", "Bearer abc", 401, ""},
+	} {
+		request := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		request.Header.Set(HeaderRequestID, "auth")
+		if tc.authorization != "" {
+			request.Header.Set("Authorization", tc.authorization)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, request)
+		assert.Equal(t, tc.code, rec.Code, name)
+		if tc.code == 401 {
+			assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"), name)
+		}
+		if tc.expect != "" && tc.expect[0] == '{' {
+			assert.JSONEq(t, tc.expect, rec.Body.String(), name)
+		} else if tc.expect != "" {
+			assert.Equal(t, tc.expect, rec.Body.String(), name)
+		}
+	}
+}
+
+func TestAuth_RSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	r := authRouter(&JWTAuthenticator{PublicKey: &key.PublicKey})
+
+	for name, tc := range map[string]struct {
+		token string
+		code  int
+	}{
+		"valid": {signToken(t, "RS256", map[string]interface{}{"sub": "joe"}, key), 200},
+		// tokens signed with the public key as an hmac secret can't be used against rsa authenticators
+		"hmac": {signToken(t, "HS256", map[string]interface{}{"sub": "joe"}, []byte("public key")), 401},
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/optional", nil)
+		request.Header.Set("Authorization", "Bearer "+tc.token)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, request)
+		assert.Equal(t, tc.code, rec.Code, name)
+	}
+}
+
+func TestAuth_EmptySecret(t *testing.T) {
+	// an unset env var gives an empty secret, tokens signed with an empty key must not authenticate
+	forged := signToken(t, "HS256", map[string]interface{}{"sub": "admin"}, []byte{})
+	for name, tc := range map[string]struct {
+		authenticator *JWTAuthenticator
+		code          int
+	}{
+		"no key":     {&JWTAuthenticator{Secret: []byte("")}, http.StatusInternalServerError},
+		"public key": {&JWTAuthenticator{Secret: []byte(""), PublicKey: &rsa.PublicKey{}}, http.StatusUnauthorized},
+	} {
+		r := authRouter(tc.authenticator)
+		request := httptest.NewRequest(http.MethodGet, "/me", nil)
+		request.Header.Set("Authorization", "Bearer "+forged)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, request)
+		assert.Equal(t, tc.code, rec.Code, name)
+		assert.NotContains(t, rec.Body.String(), "admin", name)
+	}
+
+	_, err := (&JWTAuthenticator{}).Verify(forged)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrInvalidCredentials), "a missing key is a configuration error")
+}
+
+func TestAuth_Basic(t *testing.T) {
+	r := authRouter(Authenticators{
+		&JWTAuthenticator{Secret: testSecret},
+		&BasicAuthenticator{Validate: func(ctx Context, user, password string) (bool, error) {
+			return user == "joe" && password == "hunter2", nil
+		}},
+	})
+
+	for name, tc := range map[string]struct {
+		user, password string
+		code           int
+	}{
+		"valid": {"joe", "hunter2", 200},
+		"wrong": {"joe", "hunter3", 401},
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/optional", nil)
+		request.SetBasicAuth(tc.user, tc.password)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, request)
+		assert.Equal(t, tc.code, rec.Code, name)
+		if tc.code == 401 {
+			assert.Equal(t, `Bearer, Basic realm="Restricted"`, rec.Header().Get("WWW-Authenticate"), name)
+		}
+	}
+}
+
+func TestAuth_NoAuthenticator(t *testing.T) {
+	r := New()
+	assert.PanicsWithError(t, "invalid userHandler for GET /me:\n\targ0.User (cuttle.Principal): no Authenticator is set\n\targ0.Org (string): no Authenticator is set", func() {
+		r.GET("/me", func(p struct {
+			User Principal
+			Org  string `bind:"claims"`
+		}) error {
+			return nil
+		})
+	})
+}
+
+// nilAuthenticator finds no principal without saying so
+type nilAuthenticator struct{}
+
+func (nilAuthenticator) Authenticate(ctx Context) (*Principal, error) {
+	return nil, nil
+}
+
+func TestAuth_Failures(t *testing.T) {
+	// errors of the authenticator itself are a 500, they aren't shown to the client
+	r := authRouter(&BasicAuthenticator{Validate: func(ctx Context, user, password string) (bool, error) {
+		return false, errors.New("db down")
+	}})
+	request := httptest.NewRequest(http.MethodGet, "/me", nil)
+	request.SetBasicAuth("joe", "hunter2")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, request)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "db down")
+	assert.Empty(t, rec.Header().Get("WWW-Authenticate"))
+
+	// a nil principal is no credentials
+	r = authRouter(nilAuthenticator{})
+	r.GET("/orders", func(p struct {
+		_ Scopes `scopes:"orders:read"`
+	}) error {
+		return nil
+	})
+	for path, code := range map[string]int{"/me": 401, "/orders": 401, "/optional": 200} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, code, rec.Code, path)
+	}
+}
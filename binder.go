@@ -0,0 +1,94 @@
+package cuttle
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+var binderType = reflect.TypeOf((*Binder)(nil)).Elem()
+
+// Binder is implemented by param structs with a generated binder, Method uses it instead of reflection.
+// Generate it with `go run github.com/nokusukun/cuttle/cmd/cuttle-gen -type Params`.
+type Binder interface {
+	// Bind sets the fields from the request, echo.HTTPErrors are returned as the error
+	Bind(ctx Context) ([]ValidationFail, error)
+}
+
+// BindSource is where a field is read from, parsed from its bind and as tags.
+// It's used by the reflective binder and generated binders alike so both behave the same.
+type BindSource struct {
+	resolvers ContextResolvers
+	key       lookupKey
+	option    CSRGetOption
+}
+
+// NewBindSource parses the struct tag of a field, panics on invalid tags
+func NewBindSource(fieldName string, structTag string) BindSource {
+	tags, err := parseFieldTags(fieldName, reflect.StructTag(structTag))
+	if err != nil {
+		panic(fmt.Sprintf("invalid tag on '%v': %v", fieldName, err))
+	}
+	return tags.source()
+}
+
+func (s BindSource) String(ctx Context) (string, error) {
+	return s.resolvers.lookup(s.key, s.option, ctx)
+}
+
+func (s BindSource) Int(ctx Context, bits int) (int64, error) {
+	get, err := s.String(ctx)
+	if err != nil {
+		return 0, err
+	}
+	val, err := strconv.ParseInt(get, 10, bits)
+	if err != nil {
+		return 0, s.fail("not a number", err)
+	}
+	return val, nil
+}
+
+func (s BindSource) Uint(ctx Context, bits int) (uint64, error) {
+	get, err := s.String(ctx)
+	if err != nil {
+		return 0, err
+	}
+	val, err := strconv.ParseUint(get, 10, bits)
+	if err != nil {
+		return 0, s.fail("not a number", err)
+	}
+	return val, nil
+}
+
+func (s BindSource) Float(ctx Context, bits int) (float64, error) {
+	get, err := s.String(ctx)
+	if err != nil {
+		return 0, err
+	}
+	val, err := strconv.ParseFloat(get, bits)
+	if err != nil {
+		return 0, s.fail("not a number", err)
+	}
+	return val, nil
+}
+
+func (s BindSource) Bool(ctx Context) (bool, error) {
+	get, err := s.String(ctx)
+	if err != nil {
+		return false, err
+	}
+	val, err := strconv.ParseBool(get)
+	if err != nil {
+		return false, s.fail("not a boolean", err)
+	}
+	return val, nil
+}
+
+// fail wraps a parse error, the error of secret fields is left out since it has the value
+func (s BindSource) fail(reason string, err error) error {
+	if s.option.Secret {
+		return errors.New(reason)
+	}
+	return fmt.Errorf("%v: %w", reason, err)
+}
@@ -0,0 +1,117 @@
+package cuttle
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const bodyLimitKey = "cuttle.bodylimit"
+
+var (
+	readerType     = reflect.TypeOf((*io.Reader)(nil)).Elem()
+	readCloserType = reflect.TypeOf((*io.ReadCloser)(nil)).Elem()
+	bytesType      = reflect.TypeOf([]byte(nil))
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+var ErrInvalidJson = fmt.Errorf("body is not valid json")
+
+// limitBody wraps the request body with http.MaxBytesReader, reading past the limit fails with a 413
+func limitBody(ctx Context, limit int64) {
+	if limit <= 0 {
+		return
+	}
+	if current, ok := ctx.Get(bodyLimitKey).(int64); ok && current <= limit {
+		return
+	}
+	ctx.Set(bodyLimitKey, limit)
+	ctx.Request().Body = http.MaxBytesReader(ctx.Response(), ctx.Request().Body, limit)
+}
+
+// isBodyTooLarge checks for the error returned by http.MaxBytesReader
+func isBodyTooLarge(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "http: request body too large")
+}
+
+// bodyLimit returns the field's maxbytes option or the router default
+func (r *Cuttle) bodyLimit(option CSRGetOption) int64 {
+	if option.MaxBytes > 0 {
+		return option.MaxBytes
+	}
+	return r.MaxBodyBytes
+}
+
+// isBodyType checks for the types bound to the body without a bind tag
+func isBodyType(t reflect.Type) bool {
+	return t == readerType || t == readCloserType || t == rawMessageType || t == bytesType
+}
+
+// bodyResolver returns the resolver for body fields, or nil if the field isn't bound to the body.
+// io.Reader, io.ReadCloser, []byte and json.RawMessage are the body unless the bind tag says otherwise, strings
+// need `bind:"body"`
+func (r *Cuttle) bodyResolver(t reflect.Type, tags fieldTags) ResolverFunc {
+	option := tags.option
+	if isBodyType(t) && !tags.bindBody && !tags.defaultBind {
+		return nil
+	}
+	readAll := func(ctx Context) ([]byte, error) {
+		limitBody(ctx, r.bodyLimit(option))
+		b, err := ioutil.ReadAll(ctx.Request().Body)
+		if isBodyTooLarge(err) {
+			return nil, echo.ErrStatusRequestEntityTooLarge
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(b) == 0 && option.Required {
+			return nil, ErrNoValueOnRequiredField
+		}
+		return b, nil
+	}
+
+	switch {
+	case t == readerType:
+		return func(ctx Context) (interface{}, error) {
+			limitBody(ctx, r.bodyLimit(option))
+			return bufio.NewReader(ctx.Request().Body), nil
+		}
+	case t == readCloserType:
+		return func(ctx Context) (interface{}, error) {
+			limitBody(ctx, r.bodyLimit(option))
+			return ctx.Request().Body, nil
+		}
+	case t == rawMessageType:
+		return func(ctx Context) (interface{}, error) {
+			b, err := readAll(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if len(b) != 0 && !json.Valid(b) {
+				return nil, ErrInvalidJson
+			}
+			return json.RawMessage(b), nil
+		}
+	case t == bytesType:
+		return func(ctx Context) (interface{}, error) {
+			return readAll(ctx)
+		}
+	case t.Kind() == reflect.String && tags.bindBody:
+		return func(ctx Context) (interface{}, error) {
+			b, err := readAll(ctx)
+			if err != nil {
+				return nil, err
+			}
+			ret := reflect.New(t)
+			ret.Elem().SetString(string(b))
+			return ret.Elem().Interface(), nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package cuttle
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler_BodyFields(t *testing.T) {
+	expect := `{"hello":"world"}`
+	r := New()
+	r.POST("/bytes", func(params struct {
+		Body []byte
+		Ctx  Context `json:"-"`
+	}) error {
+		assert.Equal(t, expect, string(params.Body))
+		return params.Ctx.NoContent(200)
+	})
+	r.POST("/string", func(params struct {
+		Body string  `bind:"body"`
+		Ctx  Context `json:"-"`
+	}) error {
+		assert.Equal(t, expect, params.Body)
+		return params.Ctx.NoContent(200)
+	})
+	r.POST("/raw", func(params struct {
+		Body json.RawMessage `as:",required"`
+		Ctx  Context         `json:"-"`
+	}) error {
+		assert.JSONEq(t, expect, string(params.Body))
+		return params.Ctx.NoContent(200)
+	})
+	r.POST("/closer", func(params struct {
+		Body io.ReadCloser
+		Ctx  Context `json:"-"`
+	}) error {
+		defer params.Body.Close()
+		all, err := ioutil.ReadAll(params.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, expect, string(all))
+		return params.Ctx.NoContent(200)
+	})
+
+	for _, path := range []string{"/bytes", "/string", "/raw", "/closer"} {
+		request, err := http.NewRequest("POST", "http://localhost"+path, bytes.NewBufferString(expect))
+		assert.NoError(t, err)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, request)
+		assert.Equal(t, 200, w.Code, path)
+	}
+
+	request, err := http.NewRequest("POST", "http://localhost/raw", bytes.NewBufferString("{not json"))
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandler_BodyBindTag(t *testing.T) {
+	r := New()
+	r.CollectErrors = true
+	r.POST("/webhook", func(params struct {
+		Sig     []byte          `bind:"header" as:"X-Sig"`
+		Payload json.RawMessage `bind:"query,body"`
+	}) error {
+		return nil
+	})
+	assert.EqualError(t, r.Check(), "invalid userHandler for POST /webhook:\n"+
+		"\targ0.Sig ([]uint8): can only be bound to the body, add body to the bind tag")
+}
+
+func TestHandler_MaxBytes(t *testing.T) {
+	r := New()
+	r.MaxBodyBytes = 8
+	r.POST("/tagged", func(params struct {
+		Body []byte  `as:",maxbytes=4"`
+		Ctx  Context `json:"-"`
+	}) error {
+		return params.Ctx.NoContent(200)
+	})
+	r.POST("/default", func(params struct {
+		Body io.Reader
+		Ctx  Context `json:"-"`
+	}) error {
+		_, err := ioutil.ReadAll(params.Body)
+		if err != nil {
+			return err
+		}
+		return params.Ctx.NoContent(200)
+	})
+	r.POST("/json", func(params struct {
+		FromJson
+		Name string `json:"name"`
+	}, ctx Context) error {
+		return ctx.NoContent(200)
+	})
+
+	for path, expect := range map[string]map[string]int{
+		"/tagged":  {"1234": 200, "12345": http.StatusRequestEntityTooLarge},
+		"/default": {"12345678": 200, "123456789": http.StatusRequestEntityTooLarge},
+		"/json":    {`{"a":1}`: 200, `{"name":"long"}`: http.StatusRequestEntityTooLarge},
+	} {
+		for body, code := range expect {
+			request, err := http.NewRequest("POST", "http://localhost"+path, strings.NewReader(body))
+			assert.NoError(t, err)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, request)
+			assert.Equal(t, code, w.Code, path, body)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package cuttle
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldError is a handler argument or struct field that cuttle can't bind
+type FieldError struct {
+	// Path of the field starting from the handler argument > arg0.Nested.Query
+	Path   string
+	Type   reflect.Type
+	Reason string
+}
+
+func (e FieldError) Error() string {
+	if e.Type == nil {
+		return fmt.Sprintf("%v: %v", e.Path, e.Reason)
+	}
+	return fmt.Sprintf("%v (%v): %v", e.Path, e.Type, e.Reason)
+}
+
+// HandlerError lists every problem found while registering a handler
+type HandlerError struct {
+	Method string
+	Path   string
+	Fields []FieldError
+}
+
+func (e *HandlerError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "invalid userHandler for %v %v:", e.Method, e.Path)
+	for _, field := range e.Fields {
+		sb.WriteString("\n\t")
+		sb.WriteString(field.Error())
+	}
+	return sb.String()
+}
+
+func (e *HandlerError) add(path string, t reflect.Type, reason string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{
+		Path:   path,
+		Type:   t,
+		Reason: fmt.Sprintf(reason, args...),
+	})
+}
+
+// RegistrationErrors are the handler errors collected while CollectErrors is set
+type RegistrationErrors []*HandlerError
+
+func (e RegistrationErrors) Error() string {
+	var errs []string
+	for _, handlerErr := range e {
+		errs = append(errs, handlerErr.Error())
+	}
+	return strings.Join(errs, "\n")
+}
+
+// Check returns the errors of every invalid handler registered while CollectErrors was set, handlers with
+// errors are not added to the router.
+//
+//	r := cuttle.New()
+//	r.CollectErrors = true
+//	registerRoutes(r)
+//	if err := r.Check(); err != nil {
+//	    t.Fatal(err)
+//	}
+func (r *Cuttle) Check() error {
+	if len(r.registrationErrs) == 0 {
+		return nil
+	}
+	return r.registrationErrs
+}
+
+// MustCompile panics with every collected handler error, use after registering the routes with CollectErrors set
+func (r *Cuttle) MustCompile() {
+	if err := r.Check(); err != nil {
+		panic(err)
+	}
+}
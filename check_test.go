@@ -0,0 +1,68 @@
+package cuttle
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCuttle_Check(t *testing.T) {
+	r := New()
+	r.CollectErrors = true
+	r.GET("/ok", func(params struct {
+		Query string
+		Debug bool
+	}, ctx Context) error {
+		return nil
+	})
+	assert.NoError(t, r.Check())
+
+	type Nested struct {
+		Callback func()
+	}
+	r.GET("/bad", func(params struct {
+		Meta   map[string][]string
+		Events chan string
+		Nested Nested
+		Files  []byte `as:",maxbytes=lots"`
+	}, count int) (string, error) {
+		return "", nil
+	})
+
+	err := r.Check()
+	assert.Error(t, err)
+	errs, ok := err.(RegistrationErrors)
+	assert.True(t, ok)
+	assert.Len(t, errs, 1)
+
+	var paths []string
+	for _, field := range errs[0].Fields {
+		paths = append(paths, field.Path)
+	}
+	assert.Equal(t, []string{"userHandler", "arg0.Meta", "arg0.Events", "arg0.Nested.Callback", "arg0.Files", "arg1"}, paths)
+	assert.Len(t, r.Routes(), 1, "invalid handlers should not be registered")
+	assert.Panics(t, r.MustCompile)
+}
+
+func TestCuttle_InvalidHandlerPanics(t *testing.T) {
+	r := New()
+	assert.PanicsWithError(t, "invalid userHandler for GET /test:\n\targ0 (*int): unsupported argument type, register a provider with Provide or ProvideValue", func() {
+		r.GET("/test", func(count *int) error {
+			return nil
+		})
+	})
+}
+
+func TestCuttle_CheckTags(t *testing.T) {
+	r := New()
+	r.CollectErrors = true
+	r.GET("/search", func(params struct {
+		Q     string `bind:"qeury"`
+		Limit int    `as:"limit,requried"`
+		Token string `bind:"header" as:"X-Token,secret,required"`
+	}) error {
+		return nil
+	})
+	assert.EqualError(t, r.Check(), "invalid userHandler for GET /search:\n"+
+		"\targ0.Q (string): unknown bind source 'qeury'\n"+
+		"\targ0.Limit (int): unknown as option 'requried'")
+}
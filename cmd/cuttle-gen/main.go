@@ -0,0 +1,417 @@
+// cuttle-gen generates binders for cuttle param structs, the generated Bind method is used by cuttle instead of
+// reflection and behaves the same as the reflective binder.
+//
+//	//go:generate go run github.com/nokusukun/cuttle/cmd/cuttle-gen -type SearchParams,OrderParams
+//
+// Only string, bool, numeric, Context, RequestID and nested struct fields are supported, structs with file, body or claims fields
+// have to use the reflective binder.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const cuttleImport = "github.com/nokusukun/cuttle"
+
+func main() {
+	typeNames := flag.String("type", "", "comma separated list of param struct names")
+	output := flag.String("output", "", "output file name, defaults to <type>_binder.go")
+	flag.Parse()
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	types := strings.Split(*typeNames, ",")
+
+	src, err := generate(dir, types)
+	if err != nil {
+		log.Fatalf("cuttle-gen: %v", err)
+	}
+
+	name := *output
+	if name == "" {
+		name = strings.ToLower(types[0]) + "_binder.go"
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name), src, 0644); err != nil {
+		log.Fatalf("cuttle-gen: %v", err)
+	}
+}
+
+// pkg is the parsed package holding the param structs
+type pkg struct {
+	name string
+	// qualifier for the cuttle package, empty when generating inside cuttle itself
+	cuttle string
+	// importCuttle is set when no file of the package imports cuttle yet
+	importCuttle bool
+	types        map[string]ast.Expr
+}
+
+func generate(dir string, typeNames []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := findPackage(pkgs, typeNames)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{pkg: p}
+	for _, name := range typeNames {
+		if err := g.generateType(name); err != nil {
+			return nil, err
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by cuttle-gen. DO NOT EDIT.\n\npackage %v\n\n", p.name)
+	if p.importCuttle {
+		fmt.Fprintf(&out, "import %q\n\n", cuttleImport)
+	}
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+// findPackage returns the package declaring the first type, test files are included
+func findPackage(pkgs map[string]*ast.Package, typeNames []string) (*pkg, error) {
+	var names []string
+	for name := range pkgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := &pkg{name: name, types: map[string]ast.Expr{}}
+		cuttleName := ""
+		for _, file := range pkgs[name].Files {
+			for _, imp := range file.Imports {
+				if path, _ := strconv.Unquote(imp.Path.Value); path == cuttleImport {
+					cuttleName = "cuttle"
+					if imp.Name != nil {
+						cuttleName = imp.Name.Name
+					}
+				}
+			}
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					ts := spec.(*ast.TypeSpec)
+					p.types[ts.Name.Name] = ts.Type
+				}
+			}
+		}
+		if _, ok := p.types[typeNames[0]]; !ok {
+			continue
+		}
+
+		switch {
+		case cuttleName != "":
+			p.cuttle = cuttleName + "."
+		case name == "cuttle":
+			p.cuttle = ""
+		default:
+			p.cuttle = "cuttle."
+			p.importCuttle = true
+		}
+		return p, nil
+	}
+	return nil, fmt.Errorf("type '%v' not found", typeNames[0])
+}
+
+type generator struct {
+	pkg *pkg
+	buf bytes.Buffer
+}
+
+// field is a bound field of the generated struct
+type field struct {
+	// access is the selector from the receiver > p.Inner.Limit
+	access string
+	// name is reported in ValidationFail > Inner.Limit
+	name   string
+	goName string
+	tag    string
+	// namePrefix is the prefix tag of the structs the field is in
+	namePrefix string
+	// conversion is the field's type, used to convert the parsed value
+	conversion string
+	kind       string
+	bits       int
+	context    bool
+	requestID  bool
+}
+
+var scalars = map[string]struct {
+	kind string
+	bits int
+}{
+	"string":  {"String", 0},
+	"bool":    {"Bool", 0},
+	"int":     {"Int", 0},
+	"int8":    {"Int", 8},
+	"int16":   {"Int", 16},
+	"int32":   {"Int", 32},
+	"int64":   {"Int", 64},
+	"uint":    {"Uint", 0},
+	"uint8":   {"Uint", 8},
+	"uint16":  {"Uint", 16},
+	"uint32":  {"Uint", 32},
+	"uint64":  {"Uint", 64},
+	"float32": {"Float", 32},
+	"float64": {"Float", 64},
+}
+
+func (g *generator) generateType(name string) error {
+	expr, ok := g.pkg.types[name]
+	if !ok {
+		return fmt.Errorf("type '%v' not found", name)
+	}
+	st, ok := expr.(*ast.StructType)
+	if !ok {
+		return fmt.Errorf("type '%v' is not a struct", name)
+	}
+	if len(st.Fields.List) > 0 && len(st.Fields.List[0].Names) == 0 {
+		if marker := typeName(st.Fields.List[0].Type); marker == "FromJson" || marker == "AsReturn" {
+			return fmt.Errorf("type '%v' is a %v struct, it isn't bound field by field", name, marker)
+		}
+	}
+
+	fields, err := g.fields(st, "p.", "", "", name)
+	if err != nil {
+		return err
+	}
+
+	c := g.pkg.cuttle
+	sources := "_" + name + "Sources"
+	w := &g.buf
+	fmt.Fprintf(w, "var %v = [...]%vBindSource{\n", sources, c)
+	for _, f := range fields {
+		switch {
+		case f.context || f.requestID:
+		case f.namePrefix != "":
+			fmt.Fprintf(w, "%vNewBindSource(%q, %q).WithPrefix(%q),\n", c, f.goName, f.tag, f.namePrefix)
+		default:
+			fmt.Fprintf(w, "%vNewBindSource(%q, %q),\n", c, f.goName, f.tag)
+		}
+	}
+	fmt.Fprintf(w, "}\n\n")
+
+	fmt.Fprintf(w, "// Bind sets the fields of %v from the request\n", name)
+	fmt.Fprintf(w, "func (p *%v) Bind(ctx %vContext) ([]%vValidationFail, error) {\n", name, c, c)
+	fmt.Fprintf(w, "var failures []%vValidationFail\n", c)
+	i := 0
+	for _, f := range fields {
+		if f.context {
+			fmt.Fprintf(w, "%v = ctx\n", f.access)
+			continue
+		}
+		if f.requestID {
+			fmt.Fprintf(w, "%v = %vGetRequestID(ctx)\n", f.access, c)
+			continue
+		}
+		var args string
+		if f.kind != "String" && f.kind != "Bool" {
+			args = fmt.Sprintf(", %v", f.bits)
+		}
+		fmt.Fprintf(w, "if v, err := %v[%v].%v(ctx%v); err != nil {\n", sources, i, f.kind, args)
+		fmt.Fprintf(w, "failures = append(failures, %vValidationFail{Field: %q, Err: err.Error()})\n", c, f.name)
+		fmt.Fprintf(w, "} else {\n%v = %v(v)\n}\n", f.access, f.conversion)
+		i++
+	}
+	fmt.Fprintf(w, "return failures, nil\n}\n\n")
+	return nil
+}
+
+// fields flattens the bound fields of a struct in the same order as the reflective binder,
+// prefix is added to the reported names and namePrefix to the names read from the request
+func (g *generator) fields(st *ast.StructType, access, prefix, namePrefix, path string) ([]field, error) {
+	var fields []field
+	for _, f := range st.Fields.List {
+		var tag string
+		if f.Tag != nil {
+			var err error
+			if tag, err = strconv.Unquote(f.Tag.Value); err != nil {
+				return nil, err
+			}
+		}
+		structTag := reflect.StructTag(tag)
+
+		names := make([]string, 0, len(f.Names))
+		for _, n := range f.Names {
+			names = append(names, n.Name)
+		}
+		embedded := len(names) == 0
+		if embedded {
+			names = append(names, typeName(f.Type))
+		}
+
+		for _, name := range names {
+			fieldPath := path + "." + name
+			if !ast.IsExported(name) {
+				continue
+			}
+			if _, ok := structTag.Lookup("return"); ok {
+				continue
+			}
+			if bind, ok := structTag.Lookup("bind"); ok {
+				for _, source := range strings.Split(bind, ",") {
+					if source == "body" || source == "claims" {
+						return nil, fmt.Errorf("%v: %v fields are not supported", fieldPath, source)
+					}
+				}
+			}
+
+			if g.isContext(f.Type) {
+				fields = append(fields, field{access: access + name, context: true})
+				continue
+			}
+			if g.isCuttle(f.Type, "RequestID") {
+				fields = append(fields, field{access: access + name, requestID: true})
+				continue
+			}
+
+			if nested, ok := g.structType(f.Type); ok {
+				fragment, inline, err := prefixTag(structTag)
+				if err != nil {
+					return nil, fmt.Errorf("%v: %v", fieldPath, err)
+				}
+				nestedPrefix := prefix + name + "."
+				if embedded || inline {
+					nestedPrefix = prefix + fragment
+				}
+				nestedFields, err := g.fields(nested, access+name+".", nestedPrefix, namePrefix+fragment, fieldPath)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, nestedFields...)
+				continue
+			}
+
+			scalar, ok := g.scalar(f.Type)
+			if !ok {
+				return nil, fmt.Errorf("%v: type is not supported by cuttle-gen", fieldPath)
+			}
+			fields = append(fields, field{
+				access:     access + name,
+				name:       prefix + name,
+				goName:     name,
+				tag:        tag,
+				namePrefix: namePrefix,
+				conversion: typeName(f.Type),
+				kind:       scalars[scalar].kind,
+				bits:       scalars[scalar].bits,
+			})
+		}
+	}
+	return fields, nil
+}
+
+func (g *generator) isContext(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		return ok && t.Sel.Name == "Context" && (x.Name+"." == g.pkg.cuttle || x.Name == "echo")
+	case *ast.Ident:
+		return g.pkg.cuttle == "" && t.Name == "Context"
+	}
+	return false
+}
+
+// prefixTag parses the prefix tag of a struct field, mirrors cuttle's parsePrefixTag
+func prefixTag(structTag reflect.StructTag) (string, bool, error) {
+	tag, ok := structTag.Lookup("prefix")
+	if !ok {
+		return "", false, nil
+	}
+	options := strings.Split(tag, ",")
+	inline := false
+	for _, option := range options[1:] {
+		if option != "inline" {
+			return "", false, fmt.Errorf("unknown prefix option '%v'", option)
+		}
+		inline = true
+	}
+	return options[0], inline, nil
+}
+
+// isCuttle checks for the named type of the cuttle package
+func (g *generator) isCuttle(expr ast.Expr, name string) bool {
+	switch t := expr.(type) {
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		return ok && t.Sel.Name == name && x.Name+"." == g.pkg.cuttle
+	case *ast.Ident:
+		return g.pkg.cuttle == "" && t.Name == name
+	}
+	return false
+}
+
+// structType resolves inline and package level struct types
+func (g *generator) structType(expr ast.Expr) (*ast.StructType, bool) {
+	switch t := expr.(type) {
+	case *ast.StructType:
+		return t, true
+	case *ast.Ident:
+		if decl, ok := g.pkg.types[t.Name]; ok {
+			return g.structType(decl)
+		}
+	}
+	return nil, false
+}
+
+// scalar resolves the underlying builtin of scalar types, named types declared in the package are followed
+func (g *generator) scalar(expr ast.Expr) (string, bool) {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	if _, ok := scalars[ident.Name]; ok {
+		return ident.Name, true
+	}
+	if decl, ok := g.pkg.types[ident.Name]; ok {
+		return g.scalar(decl)
+	}
+	return "", false
+}
+
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.StarExpr:
+		return typeName(t.X)
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"testing"
+)
+
+// TestGenerate_Conformance checks the committed conformance binder is up to date with the generator
+func TestGenerate_Conformance(t *testing.T) {
+	src, err := generate("../..", []string{"conformanceParams"})
+	assert.NoError(t, err)
+
+	committed, err := ioutil.ReadFile("../../conformance_binder_test.go")
+	assert.NoError(t, err)
+	assert.Equal(t, string(committed), string(src), "run go generate")
+}
+
+func TestGenerate_Unsupported(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(dir+"/params.go", []byte(`package api
+
+import "io"
+
+type Params struct {
+	Query string
+	Body  io.Reader
+}
+`), 0644)
+	assert.NoError(t, err)
+
+	_, err = generate(dir, []string{"Params"})
+	assert.EqualError(t, err, "Params.Body: type is not supported by cuttle-gen")
+}
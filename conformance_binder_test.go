@@ -0,0 +1,110 @@
+// Code generated by cuttle-gen. DO NOT EDIT.
+
+package cuttle
+
+var _conformanceParamsSources = [...]BindSource{
+	NewBindSource("ID", "bind:\"param\""),
+	NewBindSource("Query", "as:\"q,required\""),
+	NewBindSource("Count", "bind:\"query\""),
+	NewBindSource("Ratio", "bind:\"query\""),
+	NewBindSource("Debug", "bind:\"query,header\""),
+	NewBindSource("Token", "bind:\"header\" as:\"X-Token,sensitive\""),
+	NewBindSource("Status", ""),
+	NewBindSource("Trace", "bind:\"requestid\""),
+	NewBindSource("Limit", "as:\"limit\""),
+	NewBindSource("Page", "as:\"page\""),
+	NewBindSource("PerPage", "as:\"per_page\""),
+	NewBindSource("ID", "as:\"id\"").WithPrefix("owner_"),
+	NewBindSource("Name", "").WithPrefix("owner_"),
+	NewBindSource("ID", "as:\"id\"").WithPrefix("buyer_"),
+	NewBindSource("Name", "").WithPrefix("buyer_"),
+	NewBindSource("Name", "").WithPrefix("label_"),
+}
+
+// Bind sets the fields of conformanceParams from the request
+func (p *conformanceParams) Bind(ctx Context) ([]ValidationFail, error) {
+	var failures []ValidationFail
+	if v, err := _conformanceParamsSources[0].Uint(ctx, 0); err != nil {
+		failures = append(failures, ValidationFail{Field: "ID", Err: err.Error()})
+	} else {
+		p.ID = uint(v)
+	}
+	if v, err := _conformanceParamsSources[1].String(ctx); err != nil {
+		failures = append(failures, ValidationFail{Field: "Query", Err: err.Error()})
+	} else {
+		p.Query = string(v)
+	}
+	if v, err := _conformanceParamsSources[2].Int(ctx, 8); err != nil {
+		failures = append(failures, ValidationFail{Field: "Count", Err: err.Error()})
+	} else {
+		p.Count = int8(v)
+	}
+	if v, err := _conformanceParamsSources[3].Float(ctx, 32); err != nil {
+		failures = append(failures, ValidationFail{Field: "Ratio", Err: err.Error()})
+	} else {
+		p.Ratio = float32(v)
+	}
+	if v, err := _conformanceParamsSources[4].Bool(ctx); err != nil {
+		failures = append(failures, ValidationFail{Field: "Debug", Err: err.Error()})
+	} else {
+		p.Debug = bool(v)
+	}
+	if v, err := _conformanceParamsSources[5].String(ctx); err != nil {
+		failures = append(failures, ValidationFail{Field: "Token", Err: err.Error()})
+	} else {
+		p.Token = string(v)
+	}
+	if v, err := _conformanceParamsSources[6].String(ctx); err != nil {
+		failures = append(failures, ValidationFail{Field: "Status", Err: err.Error()})
+	} else {
+		p.Status = conformanceStatus(v)
+	}
+	p.ReqID = GetRequestID(ctx)
+	if v, err := _conformanceParamsSources[7].String(ctx); err != nil {
+		failures = append(failures, ValidationFail{Field: "Trace", Err: err.Error()})
+	} else {
+		p.Trace = string(v)
+	}
+	if v, err := _conformanceParamsSources[8].Int(ctx, 0); err != nil {
+		failures = append(failures, ValidationFail{Field: "Filter.Limit", Err: err.Error()})
+	} else {
+		p.Filter.Limit = int(v)
+	}
+	if v, err := _conformanceParamsSources[9].Uint(ctx, 0); err != nil {
+		failures = append(failures, ValidationFail{Field: "Page", Err: err.Error()})
+	} else {
+		p.ConformancePage.Page = uint(v)
+	}
+	if v, err := _conformanceParamsSources[10].Uint(ctx, 0); err != nil {
+		failures = append(failures, ValidationFail{Field: "PerPage", Err: err.Error()})
+	} else {
+		p.ConformancePage.PerPage = uint(v)
+	}
+	if v, err := _conformanceParamsSources[11].Uint(ctx, 0); err != nil {
+		failures = append(failures, ValidationFail{Field: "Owner.ID", Err: err.Error()})
+	} else {
+		p.Owner.ID = uint(v)
+	}
+	if v, err := _conformanceParamsSources[12].String(ctx); err != nil {
+		failures = append(failures, ValidationFail{Field: "Owner.Name", Err: err.Error()})
+	} else {
+		p.Owner.Name = string(v)
+	}
+	if v, err := _conformanceParamsSources[13].Uint(ctx, 0); err != nil {
+		failures = append(failures, ValidationFail{Field: "buyer_ID", Err: err.Error()})
+	} else {
+		p.Buyer.ID = uint(v)
+	}
+	if v, err := _conformanceParamsSources[14].String(ctx); err != nil {
+		failures = append(failures, ValidationFail{Field: "buyer_Name", Err: err.Error()})
+	} else {
+		p.Buyer.Name = string(v)
+	}
+	if v, err := _conformanceParamsSources[15].String(ctx); err != nil {
+		failures = append(failures, ValidationFail{Field: "label_Name", Err: err.Error()})
+	} else {
+		p.ConformanceLabel.Name = string(v)
+	}
+	p.Ctx = ctx
+	return failures, nil
+}
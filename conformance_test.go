@@ -0,0 +1,94 @@
+package cuttle
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+//go:generate go run ./cmd/cuttle-gen -type conformanceParams -output conformance_binder_test.go
+
+type ConformancePage struct {
+	Page    uint `as:"page"`
+	PerPage uint `as:"per_page"`
+}
+
+// conformanceRef and ConformanceLabel are read with the prefix of the field
+type conformanceRef struct {
+	ID   uint `as:"id"`
+	Name string
+}
+
+type ConformanceLabel struct {
+	Name string
+}
+
+type conformanceStatus string
+
+type conformanceParams struct {
+	ID     uint    `bind:"param"`
+	Query  string  `as:"q,required"`
+	Count  int8    `bind:"query"`
+	Ratio  float32 `bind:"query"`
+	Debug  bool    `bind:"query,header"`
+	Token  string  `bind:"header" as:"X-Token,sensitive"`
+	Status conformanceStatus
+	ReqID  RequestID
+	Trace  string `bind:"requestid"`
+	Filter struct {
+		Limit int `as:"limit"`
+	}
+	ConformancePage
+	Owner            conformanceRef `prefix:"owner_"`
+	Buyer            conformanceRef `prefix:"buyer_,inline"`
+	ConformanceLabel `prefix:"label_"`
+	Ctx              Context `json:"-"`
+	hidden           string
+	_                error `return:"400"`
+}
+
+// conformanceReflect has no Bind method so it goes through the reflective binder
+type conformanceReflect conformanceParams
+
+// TestConformance_GeneratedBinder runs the same requests through the generated and the reflective binders
+func TestConformance_GeneratedBinder(t *testing.T) {
+	_, ok := interface{}(&conformanceParams{}).(Binder)
+	assert.True(t, ok, "conformanceParams should have a generated binder, run go generate")
+
+	r := New()
+	r.GET("/generated/:id", func(p conformanceParams) error {
+		return p.Ctx.JSON(200, p)
+	})
+	r.GET("/reflect/:id", func(p conformanceReflect) error {
+		return p.Ctx.JSON(200, p)
+	})
+
+	valid := "q=cuttle&Count=-3&ratio=0.5&debug=true&Status=open&limit=10&page=2&per_page=50" +
+		"&owner_id=1&owner_name=a&buyer_id=2&buyer_name=b&label_name=c"
+	for name, tc := range map[string]struct {
+		url     string
+		headers map[string]string
+	}{
+		"valid":            {"/1?" + valid, map[string]string{"X-Token": "secret"}},
+		"header fallback":  {"/1?q=cuttle&Count=1&Ratio=1&Status=x&limit=1&page=1&per_page=1", map[string]string{"Debug": "1"}},
+		"missing required": {"/1?Count=1&Ratio=1&Debug=0&Status=x&limit=1&page=1&per_page=1", nil},
+		"overflow":         {"/1?q=a&Count=300&Ratio=1&Debug=0&Status=x&limit=1&page=1&per_page=1", nil},
+		"bad values":       {"/x?q=a&Count=a&Ratio=b&Debug=maybe&limit=c&page=-1", nil},
+	} {
+		responses := map[string]*httptest.ResponseRecorder{}
+		for _, prefix := range []string{"/generated", "/reflect"} {
+			request, err := http.NewRequest("GET", "http://localhost"+prefix+tc.url, nil)
+			assert.NoError(t, err)
+			request.Header.Set(HeaderRequestID, "conformance")
+			for k, v := range tc.headers {
+				request.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, request)
+			responses[prefix] = w
+		}
+		assert.Equal(t, responses["/reflect"].Code, responses["/generated"].Code, name)
+		assert.Equal(t, responses["/reflect"].Body.String(), responses["/generated"].Body.String(), name)
+	}
+}
@@ -0,0 +1,14 @@
+// cuttlevet runs the cuttle handler checks as a vet tool
+//
+//	go install github.com/nokusukun/cuttle/cuttlecheck/cmd/cuttlevet
+//	go vet -vettool=$(which cuttlevet) ./...
+package main
+
+import (
+	"github.com/nokusukun/cuttle/cuttlecheck"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(cuttlecheck.Analyzer)
+}
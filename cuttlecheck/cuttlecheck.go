@@ -0,0 +1,354 @@
+// Package cuttlecheck reports mistakes in cuttle handlers that would otherwise only show up when the route is
+// registered or requested: unknown bind sources and as options, path params missing from the route,
+// file fields without a form name, unsupported field types and handlers that don't return only error.
+//
+//	go install github.com/nokusukun/cuttle/cuttlecheck/cmd/cuttlevet
+//	go vet -vettool=$(which cuttlevet) ./...
+package cuttlecheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+	"reflect"
+	"strings"
+)
+
+const cuttlePath = "github.com/nokusukun/cuttle"
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "cuttle",
+	Doc:      "check cuttle handler signatures and param struct tags",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// route registration funcs, the value is the index of the path and handler arguments
+var methods = map[string][2]int{
+	"GET": {0, 1}, "POST": {0, 1}, "PUT": {0, 1}, "DELETE": {0, 1}, "PATCH": {0, 1},
+	"HEAD": {0, 1}, "OPTIONS": {0, 1}, "CONNECT": {0, 1}, "TRACE": {0, 1},
+	"Method": {1, 2},
+}
+
+// typed generic registration funcs
+var typedFuncs = map[string][2]int{
+	"Get": {1, 2}, "Post": {1, 2}, "Put": {1, 2}, "Patch": {1, 2}, "Delete": {1, 2},
+	"Handle": {2, 3},
+}
+
+var sources = map[string]bool{
+	"query": true, "param": true, "header": true, "form": true, "file": true, "body": true, "claims": true, "requestid": true,
+}
+
+var options = map[string]bool{
+	"sensitive": true, "secret": true, "required": true, "maxsize": true, "maxbytes": true, "maxcount": true, "accept": true,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != cuttlePath {
+			return
+		}
+
+		sig := fn.Type().(*types.Signature)
+		var args [2]int
+		typed := false
+		if recv := sig.Recv(); recv != nil {
+			if !isCuttle(recv.Type(), "Cuttle") {
+				return
+			}
+			if args, ok = methods[fn.Name()]; !ok {
+				return
+			}
+		} else {
+			if args, ok = typedFuncs[fn.Name()]; !ok {
+				return
+			}
+			typed = true
+		}
+		if len(call.Args) <= args[1] {
+			return
+		}
+
+		c := &checker{pass: pass, call: call}
+		if tv, ok := pass.TypesInfo.Types[call.Args[args[0]]]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+			c.route = constant.StringVal(tv.Value)
+			c.routeKnown = true
+		}
+		c.checkHandler(pass.TypesInfo.TypeOf(call.Args[args[1]]), call.Args[args[1]], typed)
+	})
+	return nil, nil
+}
+
+type checker struct {
+	pass       *analysis.Pass
+	call       *ast.CallExpr
+	route      string
+	routeKnown bool
+}
+
+// pos reports at the field when it's declared in the package being checked
+func (c *checker) pos(v *types.Var) token.Pos {
+	if v != nil && v.Pkg() == c.pass.Pkg {
+		return v.Pos()
+	}
+	return c.call.Pos()
+}
+
+func (c *checker) checkHandler(t types.Type, expr ast.Expr, typed bool) {
+	if _, ok := types.Unalias(t).Underlying().(*types.Interface); ok {
+		// the handler is only known at runtime
+		return
+	}
+	sig, ok := types.Unalias(t).Underlying().(*types.Signature)
+	if !ok {
+		c.pass.Reportf(expr.Pos(), "cuttle handler should be a func, got %v", t)
+		return
+	}
+	if !typed {
+		results := sig.Results()
+		if !(results.Len() == 1 && isError(results.At(0).Type())) && !isEventResults(results) {
+			c.pass.Reportf(expr.Pos(), "cuttle handler should only return error or (<-chan cuttle.Event, error), got %v", results)
+		}
+		if sig.Params().Len() < 1 {
+			c.pass.Reportf(expr.Pos(), "cuttle handler should accept one or more argument")
+		}
+	}
+
+	for i := 0; i < sig.Params().Len(); i++ {
+		param := sig.Params().At(i)
+		pt := types.Unalias(param.Type())
+		if ptr, ok := pt.(*types.Pointer); ok {
+			pt = types.Unalias(ptr.Elem())
+		}
+		st, ok := pt.Underlying().(*types.Struct)
+		if !ok || st.NumFields() == 0 {
+			continue
+		}
+		// json bodies aren't bound field by field
+		if isCuttle(st.Field(0).Type(), "FromJson") || isCuttle(st.Field(0).Type(), "AsReturn") {
+			continue
+		}
+		c.checkStruct(st, param.Name(), "")
+	}
+}
+
+// checkStruct checks the fields of a param struct, prefix is the prefix tag of the structs it's in
+func (c *checker) checkStruct(st *types.Struct, path, prefix string) {
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if !field.Exported() {
+			continue
+		}
+		tag := reflect.StructTag(st.Tag(i))
+		if _, ok := tag.Lookup("return"); ok {
+			continue
+		}
+		fieldPath := path + "." + field.Name()
+		pos := c.pos(field)
+
+		name := field.Name()
+		asTag, hasAs := tag.Lookup("as")
+		if hasAs {
+			name = c.checkAs(pos, fieldPath, asTag, name)
+		}
+		name = prefix + name
+
+		var bindBody, bindParam, bindClaims bool
+		bind, hasBind := tag.Lookup("bind")
+		if hasBind {
+			for _, source := range strings.Split(bind, ",") {
+				if !sources[source] {
+					c.pass.Reportf(pos, "%v: unknown bind source '%v'", fieldPath, source)
+				}
+				bindBody = bindBody || source == "body"
+				bindParam = bindParam || source == "param"
+				bindClaims = bindClaims || source == "claims"
+			}
+		}
+
+		ft := types.Unalias(field.Type())
+		switch {
+		case isFile(ft):
+			if !hasAs {
+				c.pass.Reportf(pos, "%v: file field has no as tag, the form field name defaults to '%v'", fieldPath, field.Name())
+			}
+		case isBody(ft, false) && hasBind && !bindBody:
+			c.pass.Reportf(pos, "%v: can only be bound to the body, add body to the bind tag", fieldPath)
+		case isBody(ft, bindBody), isContext(ft), isCuttle(ft, "PartStream"), isCuttle(ft, "Principal"), isCuttle(ft, "Secret"):
+		case isCuttle(ft, "Page"):
+		case isCuttle(ft, "Sort"):
+			if tag.Get("sort") == "" {
+				c.pass.Reportf(pos, "%v: no sort tag lists the fields to sort by", fieldPath)
+			}
+		case isCuttle(ft, "Filter"):
+			if tag.Get("filter") == "" {
+				c.pass.Reportf(pos, "%v: no filter tag lists the fields to filter on", fieldPath)
+			}
+		case bindClaims && isStringSlice(ft):
+		case isScalarMap(ft):
+		case isStructSlice(ft):
+			c.checkStruct(ft.Underlying().(*types.Slice).Elem().Underlying().(*types.Struct), fieldPath+"[]", "")
+		case isScalar(ft):
+			if bindParam && c.routeKnown && !hasPathParam(c.route, name) {
+				c.pass.Reportf(pos, "%v: path parameter '%v' is not in route '%v'", fieldPath, name, c.route)
+			}
+		default:
+			if nested, ok := ft.Underlying().(*types.Struct); ok {
+				c.checkStruct(nested, fieldPath, prefix+c.checkPrefix(pos, fieldPath, tag))
+				continue
+			}
+			c.pass.Reportf(pos, "%v: unsupported field type %v", fieldPath, field.Type())
+		}
+	}
+}
+
+// checkPrefix reports unknown prefix options and returns the prefix, mirrors cuttle's parsePrefixTag
+func (c *checker) checkPrefix(pos token.Pos, fieldPath string, tag reflect.StructTag) string {
+	options := strings.Split(tag.Get("prefix"), ",")
+	for _, option := range options[1:] {
+		if option != "inline" {
+			c.pass.Reportf(pos, "%v: unknown prefix option '%v'", fieldPath, option)
+		}
+	}
+	return options[0]
+}
+
+// checkAs reports unknown options and returns the name looked up from the request, mirrors cuttle's getTags
+func (c *checker) checkAs(pos token.Pos, fieldPath, asTag, name string) string {
+	parts := strings.Split(asTag, ",")
+	if parts[0] != "" {
+		name = parts[0]
+	}
+	var lastKey string
+	for _, v := range parts[1:] {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) == 1 && lastKey == "accept" && strings.Contains(v, "/") {
+			continue
+		}
+		lastKey = kv[0]
+		if !options[kv[0]] {
+			c.pass.Reportf(pos, "%v: unknown as option '%v'", fieldPath, kv[0])
+		}
+	}
+	return name
+}
+
+// hasPathParam checks the route for a `:name` segment, the lookup falls back to the lowercase name like cuttle does
+func hasPathParam(route, name string) bool {
+	for _, segment := range strings.Split(route, "/") {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		// strip the constraint > :id<int>
+		param := segment[1:]
+		if i := strings.IndexByte(param, '<'); i >= 0 {
+			param = param[:i]
+		}
+		if param == name || param == strings.ToLower(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCuttle(t types.Type, name string) bool {
+	t = types.Unalias(t)
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = types.Unalias(ptr.Elem())
+	}
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == cuttlePath && named.Obj().Name() == name
+}
+
+func isNamed(t types.Type, pkg, name string) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == pkg && named.Obj().Name() == name
+}
+
+func isError(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+// isEventResults checks for the results of handlers sending server-sent events > (<-chan cuttle.Event, error)
+func isEventResults(results *types.Tuple) bool {
+	if results.Len() != 2 || !isError(results.At(1).Type()) {
+		return false
+	}
+	ch, ok := types.Unalias(results.At(0).Type()).(*types.Chan)
+	return ok && ch.Dir() == types.RecvOnly && isCuttle(ch.Elem(), "Event")
+}
+
+func isContext(t types.Type) bool {
+	return isCuttle(t, "Context") || isNamed(t, "github.com/labstack/echo/v4", "Context")
+}
+
+func isFile(t types.Type) bool {
+	if slice, ok := types.Unalias(t).(*types.Slice); ok {
+		t = slice.Elem()
+	}
+	if ptr, ok := types.Unalias(t).(*types.Pointer); ok {
+		return isNamed(ptr.Elem(), "mime/multipart", "FileHeader")
+	}
+	return isCuttle(t, "File")
+}
+
+func isBody(t types.Type, bindBody bool) bool {
+	if isNamed(t, "io", "Reader") || isNamed(t, "io", "ReadCloser") || isNamed(t, "encoding/json", "RawMessage") {
+		return true
+	}
+	// json.RawMessage is an alias of jsontext.Value with the jsonv2 experiment
+	if isNamed(t, "encoding/json/jsontext", "Value") {
+		return true
+	}
+	if slice, ok := types.Unalias(t).(*types.Slice); ok {
+		if basic, ok := types.Unalias(slice.Elem()).(*types.Basic); ok && basic.Kind() == types.Byte {
+			return true
+		}
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	return bindBody && ok && basic.Kind() == types.String
+}
+
+func isStringSlice(t types.Type) bool {
+	slice, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	basic, ok := slice.Elem().Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.String
+}
+
+// isScalarMap checks for deep object maps > map[string]int
+func isScalarMap(t types.Type) bool {
+	m, ok := t.Underlying().(*types.Map)
+	return ok && isString(m.Key()) && isScalar(m.Elem())
+}
+
+// isStructSlice checks for slices bound from indexed keys > []Item
+func isStructSlice(t types.Type) bool {
+	slice, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	_, ok = slice.Elem().Underlying().(*types.Struct)
+	return ok
+}
+
+func isString(t types.Type) bool {
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.String
+}
+
+func isScalar(t types.Type) bool {
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&(types.IsString|types.IsBoolean|types.IsInteger|types.IsFloat) != 0 && basic.Kind() != types.Uintptr
+}
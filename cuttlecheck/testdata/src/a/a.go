@@ -0,0 +1,95 @@
+package a
+
+import (
+	"encoding/json"
+	"github.com/nokusukun/cuttle"
+	"io"
+	"mime/multipart"
+)
+
+type Nested struct {
+	Tags   map[string][]string // want `p.Nested.Tags: unsupported field type map\[string\]\[\]string`
+	Labels map[string]int      `as:"labels"`
+	Items  []struct {
+		ID  uint   `as:"id"`
+		Bad func() // want `p.Nested.Items\[\].Bad: unsupported field type func\(\)`
+	}
+}
+
+func routes(r *cuttle.Cuttle) {
+	r.GET("/users/:id<uint>", func(p struct {
+		ID     uint   `bind:"param"`
+		Org    string `bind:"param" as:"org"` // want `p.Org: path parameter 'org' is not in route '/users/:id<uint>'`
+		Query  string `bind:"qeury"`          // want `p.Query: unknown bind source 'qeury'`
+		Q      string `as:"q,requried"`       // want `p.Q: unknown as option 'requried'`
+		Accept string `as:"a,accept=image/png,image/jpeg,maxsize=1MB"`
+		Ctx    cuttle.Context
+		User   *cuttle.Principal
+		Roles  []string              `bind:"claims"`
+		Token  cuttle.Secret[string] `bind:"header" as:"X-Token,secret"`
+		ReqID  cuttle.RequestID
+		Trace  string        `bind:"requestid"`
+		Page   cuttle.Page   `page:"max=50"`
+		Sort   cuttle.Sort   `sort:"name"`
+		Order  cuttle.Sort   // want `p.Order: no sort tag lists the fields to sort by`
+		Filter cuttle.Filter // want `p.Filter: no filter tag lists the fields to filter on`
+		Tags   []string      // want `p.Tags: unsupported field type \[\]string`
+		Nested
+		hidden chan int
+		_      error `return:"400"`
+	}) error {
+		return nil
+	})
+
+	r.POST("/upload", func(p *struct {
+		Avatar  *multipart.FileHeader // want `p.Avatar: file field has no as tag, the form field name defaults to 'Avatar'`
+		Images  []cuttle.File         `as:"images"`
+		Raw     json.RawMessage
+		Body    io.Reader
+		Text    string `bind:"body"`
+		Sig     []byte `bind:"header" as:"X-Sig"` // want `p.Sig: can only be bound to the body, add body to the bind tag`
+		Stream  cuttle.PartStream
+		Handler func() // want `p.Handler: unsupported field type func\(\)`
+	}, ctx cuttle.Context) error {
+		return nil
+	})
+
+	r.Method("GET", "/json", func(p struct { // want `cuttle handler should only return error or \(<-chan cuttle.Event, error\), got \(string, error\)`
+		cuttle.FromJson
+		Anything map[string]interface{}
+	}) (string, error) {
+		return "", nil
+	})
+
+	r.GET("/feed", func(p struct {
+		Since cuttle.LastEventID
+	}, ctx cuttle.Context) (<-chan cuttle.Event, error) {
+		return nil, nil
+	})
+
+	r.GET("/feed/writer", func(p struct{}, events cuttle.SSEWriter) error {
+		return nil
+	})
+
+	r.GET("/feed/chan", func(p struct{}) (chan cuttle.Event, error) { // want `cuttle handler should only return error or \(<-chan cuttle.Event, error\), got \(chan github.com/nokusukun/cuttle.Event, error\)`
+		return nil, nil
+	})
+
+	r.GET("/owners/:owner_id", func(p struct {
+		Owner struct {
+			ID uint `bind:"param" as:"id"`
+		} `prefix:"owner_"`
+		Pet struct { // want `p.Pet: unknown prefix option 'flat'`
+			ID uint `bind:"param" as:"id"` // want `p.Pet.ID: path parameter 'pet_id' is not in route '/owners/:owner_id'`
+		} `prefix:"pet_,flat"`
+	}) error {
+		return nil
+	})
+
+	cuttle.Get(r, "/typed/:slug", func(ctx cuttle.Context, p struct {
+		Slug string `bind:"param"`
+		ID   string `bind:"param"` // want `p.ID: path parameter 'ID' is not in route '/typed/:slug'`
+	}) (string, error) {
+		return "", nil
+	})
+}
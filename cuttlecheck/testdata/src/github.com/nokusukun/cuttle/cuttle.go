@@ -0,0 +1,53 @@
+// Package cuttle is a stub of the cuttle api used by the analyzer tests
+package cuttle
+
+type Context interface {
+	Param(name string) string
+}
+
+type Cuttle struct{}
+
+func (r *Cuttle) GET(path string, userHandler interface{})            {}
+func (r *Cuttle) POST(path string, userHandler interface{})           {}
+func (r *Cuttle) Method(method, path string, userHandler interface{}) {}
+
+type FromJson struct{}
+
+type File struct{}
+
+type PartStream struct{}
+
+type Principal struct{}
+
+type RequestID string
+
+type Event struct {
+	ID, Event string
+	Data      interface{}
+}
+
+type LastEventID string
+
+type SSEWriter struct {
+	state *int
+}
+
+type Page struct {
+	Number, PerPage int
+	Cursor          string
+}
+
+type Sort []struct {
+	Field string
+	Desc  bool
+}
+
+type Filter []struct {
+	Field, Op, Value string
+}
+
+type Secret[T any] struct {
+	value T
+}
+
+func Get[P, R any](r *Cuttle, path string, fn func(ctx Context, p P) (R, error)) {}
@@ -0,0 +1,275 @@
+package cuttle
+
+import (
+	"fmt"
+	"net/textproto"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// default limit of the elements of a struct slice, set with the maxcount option
+const defaultMaxItems = 100
+
+// mapBinder binds map[string]T fields from bracketed query and form keys > meta[color]=red, and from headers with
+// a prefix > `bind:"header" as:"X-Meta-*"`. Earlier sources of the bind tag win, nil if T isn't a scalar.
+func mapBinder(t reflect.Type, tags fieldTags, sources []string) fieldBinder {
+	if t.Key().Kind() != reflect.String || scalarBinder(t.Elem(), BindSource{}) == nil {
+		return nil
+	}
+	names := deepObjectNames(tags)
+	readers := make([]func(ctx Context) map[string][]string, 0, len(sources))
+	for _, source := range sources {
+		switch source {
+		case "query":
+			readers = append(readers, func(ctx Context) map[string][]string {
+				return bracketKeys(names, ctx.QueryParams())
+			})
+		case "form":
+			readers = append(readers, func(ctx Context) map[string][]string {
+				form, err := ctx.FormParams()
+				if err != nil {
+					return nil
+				}
+				return bracketKeys(names, form)
+			})
+		case "header":
+			prefix := textproto.CanonicalMIMEHeaderKey(strings.TrimSuffix(tags.name, "*"))
+			if !strings.HasSuffix(prefix, "-") {
+				prefix += "-"
+			}
+			readers = append(readers, func(ctx Context) map[string][]string {
+				values := map[string][]string{}
+				for name, v := range ctx.Request().Header {
+					if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+						values[strings.ToLower(name[len(prefix):])] = v
+					}
+				}
+				return values
+			})
+		}
+	}
+	if len(readers) == 0 {
+		return nil
+	}
+
+	return func(ctx Context, field reflect.Value) error {
+		m := reflect.MakeMap(t)
+		for _, read := range readers {
+			for key, values := range read(ctx) {
+				k := reflect.ValueOf(key).Convert(t.Key())
+				if m.MapIndex(k).IsValid() || len(values) == 0 {
+					continue
+				}
+				v, err := parseScalar(t.Elem(), values[0], tags.option)
+				if err != nil {
+					return fmt.Errorf("%v: %w", key, err)
+				}
+				m.SetMapIndex(k, v)
+			}
+		}
+		if m.Len() == 0 {
+			if tags.option.Required {
+				return ErrNoValueOnRequiredField
+			}
+			field.Set(reflect.Zero(t))
+			return nil
+		}
+		field.Set(m)
+		return nil
+	}
+}
+
+// deepObjectNames are the names a deep object is looked up by, the lowercase name is tried like for scalar fields
+func deepObjectNames(tags fieldTags) []string {
+	names := []string{tags.name}
+	if lower := strings.ToLower(tags.name); !tags.option.Sensitive && lower != tags.name {
+		names = append(names, lower)
+	}
+	return names
+}
+
+// bracketKeys returns the values of the keys of a deep object > name[key], from the first name that has any
+func bracketKeys(names []string, values map[string][]string) map[string][]string {
+	keys := map[string][]string{}
+	for _, name := range names {
+		prefix := name + "["
+		for key, v := range values {
+			if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, "]") {
+				continue
+			}
+			inner := key[len(prefix) : len(key)-1]
+			if inner != "" && !strings.ContainsAny(inner, "[]") {
+				keys[inner] = v
+			}
+		}
+		if len(keys) != 0 {
+			break
+		}
+	}
+	return keys
+}
+
+// parseScalar parses a value of the scalar type t, with the errors of BindSource
+func parseScalar(t reflect.Type, s string, option CSRGetOption) (reflect.Value, error) {
+	source := BindSource{option: option}
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, t.Bits())
+		if err != nil {
+			return v, source.fail("not a number", err)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, t.Bits())
+		if err != nil {
+			return v, source.fail("not a number", err)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return v, source.fail("not a number", err)
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return v, source.fail("not a boolean", err)
+		}
+		v.SetBool(b)
+	}
+	return v, nil
+}
+
+// itemsPlan binds a slice of structs from indexed query keys > items[0].id=1
+type itemsPlan struct {
+	elem *structPlan
+	// names of the query param, see deepObjectNames
+	names    []string
+	max      int
+	required bool
+}
+
+// checkItemFields reports the fields of slice elements that aren't read from the indexed query keys,
+// every element would get the same request-wide value
+func checkItemFields(t reflect.Type, path string, check *HandlerError) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if _, ok := field.Tag.Lookup("return"); ok {
+			continue
+		}
+		fieldPath := path + "." + field.Name
+		ft := field.Type
+		perRequest := ft.Kind() == reflect.Map || (ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct) ||
+			ft == pageType || ft == sortType || ft == filterType || ft == partStreamType || isFileType(ft) ||
+			isBodyType(ft) || ft == principalType || ft == reflect.PtrTo(principalType)
+		if bind, ok := field.Tag.Lookup("bind"); ok && bind != "query" {
+			perRequest = true
+		}
+		switch {
+		case perRequest:
+			check.add(fieldPath, ft, "only query params can be bound in slice elements")
+		case ft.Kind() == reflect.Struct && !isSecret(ft):
+			checkItemFields(ft, fieldPath, check)
+		}
+	}
+}
+
+// itemContext reads the query params of an element of a slice field, path params aren't read
+type itemContext struct {
+	Context
+	prefix string
+}
+
+func (c itemContext) QueryParam(name string) string {
+	return c.Context.QueryParam(c.prefix + name)
+}
+
+func (c itemContext) Param(name string) string {
+	return ""
+}
+
+// indexes returns the indexes of the elements in the query params sorted, and the name they were found by
+func (p *itemsPlan) indexes(ctx Context) ([]int, string, error) {
+	for _, name := range p.names {
+		indexes, err := p.indexesOf(name, ctx.QueryParams())
+		if err != nil || len(indexes) != 0 {
+			return indexes, name, err
+		}
+	}
+	return nil, "", nil
+}
+
+func (p *itemsPlan) indexesOf(name string, query map[string][]string) ([]int, error) {
+	prefix := name + "["
+	seen := map[int]bool{}
+	var indexes []int
+	for key := range query {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		end := strings.Index(key, "].")
+		if end < 0 {
+			continue
+		}
+		i, err := strconv.Atoi(key[len(prefix):end])
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("invalid index in '%v'", key)
+		}
+		if i >= p.max {
+			return nil, fmt.Errorf("at most %v items are allowed", p.max)
+		}
+		if !seen[i] {
+			seen[i] = true
+			indexes = append(indexes, i)
+		}
+	}
+	sort.Ints(indexes)
+	return indexes, nil
+}
+
+// bind sets the slice to the elements up to the highest index, missing indexes are left zero
+func (p *itemsPlan) bind(ctx Context, dst reflect.Value, name string, failures []ValidationFail) ([]ValidationFail, error) {
+	indexes, param, err := p.indexes(ctx)
+	if err != nil {
+		return append(failures, ValidationFail{Field: name, Err: err.Error()}), nil
+	}
+	if len(indexes) == 0 {
+		if p.required {
+			return append(failures, ValidationFail{Field: name, Err: ErrNoValueOnRequiredField.Error()}), nil
+		}
+		dst.Set(reflect.Zero(dst.Type()))
+		return failures, nil
+	}
+
+	items := reflect.MakeSlice(dst.Type(), indexes[len(indexes)-1]+1, indexes[len(indexes)-1]+1)
+	for _, i := range indexes {
+		index := "[" + strconv.Itoa(i) + "]"
+		item := itemContext{Context: ctx, prefix: param + index + "."}
+		failures, err = p.elem.bind(item, items.Index(i), name+index+".", failures)
+		if err != nil {
+			return failures, err
+		}
+	}
+	dst.Set(items)
+	return failures, nil
+}
+
+// redacts checks for fields left out of the request logs
+func (p *structPlan) redacts() bool {
+	for i := range p.fields {
+		f := &p.fields[i]
+		if f.redact || (f.nested != nil && f.nested.redacts()) || (f.items != nil && f.items.elem.redacts()) {
+			return true
+		}
+	}
+	return false
+}
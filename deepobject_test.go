@@ -0,0 +1,143 @@
+package cuttle
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDeepObject_Map(t *testing.T) {
+	r := New()
+	var got struct {
+		Meta   map[string]string
+		Counts map[string]int
+		Labels map[string]string
+	}
+	r.GET("/items", func(p struct {
+		Meta   map[string]string `bind:"query,header" as:"X-Meta-*"`
+		Counts map[string]int    `as:"counts"`
+		Labels map[string]string
+	}) error {
+		got.Meta, got.Counts, got.Labels = p.Meta, p.Counts, p.Labels
+		return nil
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/items?"+
+		url.Values{"counts[red]": {"1"}, "counts[blue]": {"2"}, "counts[a][b]": {"3"}, "labels[env]": {"prod"}}.Encode(), nil)
+	request.Header.Set("X-Meta-Color", "red")
+	request.Header.Set("X-Meta-Size", "xl")
+	request.Header.Set("X-Metadata", "ignored")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, request)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, map[string]string{"color": "red", "size": "xl"}, got.Meta)
+	assert.Equal(t, map[string]int{"red": 1, "blue": 2}, got.Counts)
+	// the lowercase field name is tried like for scalar fields
+	assert.Equal(t, map[string]string{"env": "prod"}, got.Labels)
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, got.Meta)
+	assert.Nil(t, got.Counts)
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items?counts[red]=many", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `{"field":"Counts","error":"red: not a number: strconv.ParseInt: parsing \"many\": invalid syntax"}`)
+}
+
+func TestDeepObject_Form(t *testing.T) {
+	r := New()
+	var got map[string]string
+	r.POST("/items", func(p struct {
+		Meta map[string]string `bind:"form" as:"meta,required"`
+	}) error {
+		got = p.Meta
+		return nil
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader("meta[color]=red&meta[]=x"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, request)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, map[string]string{"color": "red"}, got)
+
+	request = httptest.NewRequest(http.MethodPost, "/items", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, request)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `{"field":"Meta","error":"no value found on required field"}`)
+}
+
+func TestDeepObject_Items(t *testing.T) {
+	type Item struct {
+		ID       uint `as:"id,required"`
+		Quantity int
+	}
+	r := New()
+	var got []Item
+	r.GET("/orders/:id", func(p struct {
+		ID    uint   `bind:"param"`
+		Items []Item `as:"items,maxcount=3"`
+	}) error {
+		got = p.Items
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders/7?items[0].id=1&items[0].quantity=2&items[2].id=3&items[2].quantity=0", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	// the path param :id isn't read by the items
+	assert.Equal(t, []Item{{1, 2}, {}, {3, 0}}, got)
+
+	for query, failure := range map[string]string{
+		"items[0].quantity=2":        `{"field":"Items[0].ID","error":"no value found on required field"}`,
+		"items[0].id=x":              `{"field":"Items[0].ID","error":"not a number: strconv.ParseUint: parsing \"x\": invalid syntax"}`,
+		"items[3].id=1":              `{"field":"Items","error":"at most 3 items are allowed"}`,
+		"items[a].id=1":              `{"field":"Items","error":"invalid index in 'items[a].id'"}`,
+		"items[0].id=1&items[1].id=": `{"field":"Items[1].ID","error":"no value found on required field"}`,
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders/7?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		assert.Contains(t, rec.Body.String(), failure, query)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders/7", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, got)
+}
+
+func TestDeepObject_ItemSources(t *testing.T) {
+	r := New()
+	r.CollectErrors = true
+	r.POST("/orders", func(p struct {
+		Items []struct {
+			ID    uint              `as:"id"`
+			Note  string            `bind:"form"`
+			Token string            `bind:"header" as:"X-Token"`
+			Attrs map[string]string `as:"attrs"`
+			Page  Page
+			Sub   []struct{ ID uint }
+			Ref   struct {
+				Name string `bind:"query"`
+				Code string `bind:"header"`
+			}
+		} `as:"items"`
+	}) error {
+		return nil
+	})
+	assert.EqualError(t, r.Check(), "invalid userHandler for POST /orders:\n"+
+		"\targ0.Items[].Note (string): only query params can be bound in slice elements\n"+
+		"\targ0.Items[].Token (string): only query params can be bound in slice elements\n"+
+		"\targ0.Items[].Attrs (map[string]string): only query params can be bound in slice elements\n"+
+		"\targ0.Items[].Page (cuttle.Page): only query params can be bound in slice elements\n"+
+		"\targ0.Items[].Sub ([]struct { ID uint }): only query params can be bound in slice elements\n"+
+		"\targ0.Items[].Ref.Code (string): only query params can be bound in slice elements")
+}
@@ -0,0 +1,197 @@
+package cuttle
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrTooManyFiles        = fmt.Errorf("too many files")
+	ErrFileTooLarge        = fmt.Errorf("file too large")
+	ErrFileTypeNotAccepted = fmt.Errorf("file type not accepted")
+)
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+	fileType            = reflect.TypeOf(File{})
+	fileSliceType       = reflect.TypeOf([]File(nil))
+)
+
+// File is an uploaded multipart file, the content type is detected from the first 512 bytes of the file
+// rather than trusting the Content-Type sent by the client.
+type File struct {
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+	header      *multipart.FileHeader
+}
+
+// Open opens the uploaded file for reading
+func (f File) Open() (multipart.File, error) {
+	if f.header == nil {
+		return nil, http.ErrMissingFile
+	}
+	return f.header.Open()
+}
+
+// Header returns the underlying multipart file header
+func (f File) Header() *multipart.FileHeader {
+	return f.header
+}
+
+func newFile(fh *multipart.FileHeader) (File, error) {
+	contentType, err := detectContentType(fh)
+	if err != nil {
+		return File{}, err
+	}
+	return File{
+		Name:        fh.Filename,
+		Size:        fh.Size,
+		ContentType: contentType,
+		header:      fh,
+	}, nil
+}
+
+func detectContentType(fh *multipart.FileHeader) (string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// isFileType reports whether t is bound from the multipart file section of the form
+func isFileType(t reflect.Type) bool {
+	switch t {
+	case fileHeaderType, fileHeaderSliceType, fileType, fileSliceType:
+		return true
+	}
+	return false
+}
+
+// fileResolver returns the resolver for file fields, or nil if the type isn't a file type
+func fileResolver(t reflect.Type, name string, option CSRGetOption) ResolverFunc {
+	if !isFileType(t) {
+		return nil
+	}
+	return func(ctx Context) (interface{}, error) {
+		headers, err := formFiles(ctx, name, option)
+		// *multipart.FileHeader fields fail without a file like ctx.FormFile did, handlers dereference them
+		if err != nil && (t == fileHeaderType || !errors.Is(err, http.ErrNotMultipart)) {
+			return nil, err
+		}
+		if len(headers) == 0 {
+			if option.Required {
+				return nil, ErrNoValueOnRequiredField
+			}
+			if t == fileHeaderType {
+				return nil, http.ErrMissingFile
+			}
+			return reflect.Zero(t).Interface(), nil
+		}
+		if option.MaxCount > 0 && len(headers) > option.MaxCount {
+			return nil, fmt.Errorf("%w: got %v, max %v", ErrTooManyFiles, len(headers), option.MaxCount)
+		}
+		if t == fileHeaderType || t == fileType {
+			headers = headers[:1]
+		}
+
+		files := make([]File, 0, len(headers))
+		for _, fh := range headers {
+			if option.MaxSize > 0 && fh.Size > option.MaxSize {
+				return nil, fmt.Errorf("%w: '%v' is %v bytes, max %v", ErrFileTooLarge, fh.Filename, fh.Size, option.MaxSize)
+			}
+			// only sniff the content when it's needed
+			if len(option.Accept) == 0 && (t == fileHeaderType || t == fileHeaderSliceType) {
+				continue
+			}
+			file, err := newFile(fh)
+			if err != nil {
+				return nil, err
+			}
+			if !acceptsMediaType(option.Accept, file.ContentType) {
+				return nil, fmt.Errorf("%w: '%v' is %v", ErrFileTypeNotAccepted, fh.Filename, file.ContentType)
+			}
+			files = append(files, file)
+		}
+
+		switch t {
+		case fileHeaderType:
+			return headers[0], nil
+		case fileHeaderSliceType:
+			return headers, nil
+		case fileType:
+			return files[0], nil
+		default:
+			return files, nil
+		}
+	}
+}
+
+func formFiles(ctx Context, name string, option CSRGetOption) ([]*multipart.FileHeader, error) {
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	headers := form.File[name]
+	if len(headers) == 0 && !option.Sensitive {
+		headers = form.File[strings.ToLower(name)]
+	}
+	return headers, nil
+}
+
+// acceptsMediaType checks the content type against a list of accepted types, supports wildcards like `image/*`
+func acceptsMediaType(accept []string, contentType string) bool {
+	if len(accept) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	for _, a := range accept {
+		if a == "*/*" || strings.EqualFold(a, mediaType) {
+			return true
+		}
+		if strings.HasSuffix(a, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(a, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSize parses a byte size such as `1024`, `512KB` or `10MB`
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSuffix(s, unit.suffix)
+			multiplier = unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size '%v': %w", s, err)
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,100 @@
+package cuttle
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\x0D\x0A\x1A\x0A")
+
+func multipartRequest(t *testing.T, files map[string][][]byte) *http.Request {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	for key, contents := range files {
+		for i, content := range contents {
+			fw, err := w.CreateFormFile(key, key+string(rune('a'+i)))
+			assert.NoError(t, err)
+			_, err = fw.Write(content)
+			assert.NoError(t, err)
+		}
+	}
+	assert.NoError(t, w.Close())
+
+	req, err := http.NewRequest("POST", "http://localhost/upload", &b)
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestHandler_MultipleFiles(t *testing.T) {
+	r := New()
+	r.POST("/upload", func(params struct {
+		Images  []File                  `as:"images,required,maxcount=2,accept=image/png,image/jpeg"`
+		Headers []*multipart.FileHeader `as:"images"`
+		Missing []*multipart.FileHeader `as:"missing"`
+		Ctx     Context                 `json:"-"`
+	}) error {
+		assert.Len(t, params.Images, 2)
+		assert.Len(t, params.Headers, 2)
+		assert.Empty(t, params.Missing)
+		assert.Equal(t, "image/png", params.Images[0].ContentType)
+		assert.Equal(t, int64(len(pngHeader)), params.Images[0].Size)
+		return params.Ctx.JSON(200, params)
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, multipartRequest(t, map[string][][]byte{"images": {pngHeader, pngHeader}}))
+	assert.Equal(t, 200, rec.Code)
+}
+
+func TestHandler_FileValidation(t *testing.T) {
+	r := New()
+	r.POST("/upload", func(params struct {
+		Images []*multipart.FileHeader `as:"images,maxcount=1,maxsize=8B,accept=image/*"`
+		Ctx    Context                 `json:"-"`
+	}) error {
+		return params.Ctx.NoContent(200)
+	})
+
+	for name, files := range map[string][][]byte{
+		"too many":     {pngHeader, pngHeader},
+		"too large":    {append(pngHeader, 0)},
+		"not accepted": {[]byte("hello world!")},
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, multipartRequest(t, map[string][][]byte{"images": files}))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, name)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, multipartRequest(t, map[string][][]byte{"images": {pngHeader}}))
+	assert.Equal(t, 200, rec.Code)
+}
+
+func TestHandler_MissingFileHeader(t *testing.T) {
+	r := New()
+	r.POST("/upload", func(params struct {
+		Image *multipart.FileHeader `as:"image"`
+		Ctx   Context
+	}) error {
+		return params.Ctx.String(200, params.Image.Filename)
+	})
+
+	for name, request := range map[string]*http.Request{
+		"missing":       multipartRequest(t, map[string][][]byte{"other": {pngHeader}}),
+		"not multipart": httptest.NewRequest(http.MethodPost, "/upload", nil),
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, request)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, name)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, multipartRequest(t, map[string][][]byte{"image": {pngHeader}}))
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "imagea", rec.Body.String())
+}
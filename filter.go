@@ -0,0 +1,105 @@
+package cuttle
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Filter is the conditions of a list endpoint, read from query params like filter[status]=open&filter[age][gte]=18.
+// The filter tag lists the fields that can be filtered on, conditions without an operator are eq.
+//
+//	Filter cuttle.Filter `filter:"status,age"`
+type Filter []FilterCondition
+
+// FilterCondition is a condition of the filter param > filter[age][gte]=18
+type FilterCondition struct {
+	Field string
+	Op    string
+	Value string
+}
+
+// filterOps are the operators a condition can use
+var filterOps = map[string]bool{
+	"eq": true, "ne": true, "gt": true, "gte": true, "lt": true, "lte": true, "in": true, "like": true,
+}
+
+// Get returns the value of the first condition on field with op
+func (f Filter) Get(field, op string) (string, bool) {
+	for _, c := range f {
+		if c.Field == field && c.Op == op {
+			return c.Value, true
+		}
+	}
+	return "", false
+}
+
+var filterType = reflect.TypeOf(Filter(nil))
+
+// parseFilterTag returns the allowed fields of the filter tag
+func parseFilterTag(tag string) (map[string]bool, error) {
+	if tag == "" {
+		return nil, fmt.Errorf("no filter tag lists the fields to filter on")
+	}
+	allowed := map[string]bool{}
+	for _, field := range strings.Split(tag, ",") {
+		allowed[strings.TrimSpace(field)] = true
+	}
+	return allowed, nil
+}
+
+func filterBinder(name string, allowed map[string]bool) fieldBinder {
+	prefix := name + "["
+	return func(ctx Context, field reflect.Value) error {
+		var filter Filter
+		for key, values := range ctx.QueryParams() {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			c, err := parseFilterKey(key[len(name):])
+			if err != nil {
+				return fmt.Errorf("invalid filter '%v'", key)
+			}
+			if !allowed[c.Field] {
+				return fmt.Errorf("can't filter on '%v'", c.Field)
+			}
+			if !filterOps[c.Op] {
+				return fmt.Errorf("unknown filter operator '%v'", c.Op)
+			}
+			for _, value := range values {
+				c.Value = value
+				filter = append(filter, c)
+			}
+		}
+		// query params come from a map
+		sort.SliceStable(filter, func(i, j int) bool {
+			if filter[i].Field != filter[j].Field {
+				return filter[i].Field < filter[j].Field
+			}
+			return filter[i].Op < filter[j].Op
+		})
+		field.Set(reflect.ValueOf(filter))
+		return nil
+	}
+}
+
+// parseFilterKey parses the [field] or [field][op] after the param name
+func parseFilterKey(key string) (FilterCondition, error) {
+	var parts []string
+	for key != "" {
+		end := strings.IndexByte(key, ']')
+		if key[0] != '[' || end < 2 {
+			return FilterCondition{}, fmt.Errorf("invalid filter")
+		}
+		parts = append(parts, key[1:end])
+		key = key[end+1:]
+	}
+	switch len(parts) {
+	case 1:
+		return FilterCondition{Field: parts[0], Op: "eq"}, nil
+	case 2:
+		return FilterCondition{Field: parts[0], Op: parts[1]}, nil
+	}
+	return FilterCondition{}, fmt.Errorf("invalid filter")
+}
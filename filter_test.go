@@ -0,0 +1,58 @@
+package cuttle
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	r := New()
+	var got Filter
+	r.GET("/users", func(p struct {
+		Filter Filter `filter:"status,age"`
+	}) error {
+		got = p.Filter
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?filter[status]=open&filter[age][gte]=18&filter[age][lt]=65&q=x", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, Filter{
+		{"age", "gte", "18"},
+		{"age", "lt", "65"},
+		{"status", "eq", "open"},
+	}, got)
+	age, ok := got.Get("age", "gte")
+	assert.True(t, ok)
+	assert.Equal(t, "18", age)
+	_, ok = got.Get("age", "eq")
+	assert.False(t, ok)
+
+	for query, err := range map[string]string{
+		"filter[password]=x":       "can't filter on 'password'",
+		"filter[age][between]=1":   "unknown filter operator 'between'",
+		"filter[age][gte][x]=1":    "invalid filter 'filter[age][gte][x]'",
+		"filter[age=1":             "invalid filter 'filter[age'",
+		"filter[]=1":               "invalid filter 'filter[]'",
+		"filter[status]x[eq]=open": "invalid filter 'filter[status]x[eq]'",
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		assert.Contains(t, rec.Body.String(), `"error":"`+err+`"`, query)
+	}
+}
+
+func TestFilter_NoTag(t *testing.T) {
+	r := New()
+	r.CollectErrors = true
+	r.GET("/users", func(p struct {
+		Filter Filter
+	}) error {
+		return nil
+	})
+	assert.Contains(t, r.Check().Error(), "no filter tag lists the fields to filter on")
+}
@@ -0,0 +1,190 @@
+package cuttle
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var jwtHashes = map[string]crypto.Hash{
+	"HS256": crypto.SHA256, "HS384": crypto.SHA384, "HS512": crypto.SHA512,
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+}
+
+// JWTAuthenticator verifies bearer tokens signed with HS256, HS384, HS512 when Secret is set or RS256, RS384,
+// RS512 when PublicKey is set. Tokens using any other algorithm are rejected, an authenticator without a key
+// fails every bearer token with a 500 rather than accepting tokens signed with an empty secret.
+//
+//	r.Authenticator = &cuttle.JWTAuthenticator{Secret: []byte(os.Getenv("JWT_SECRET")), Issuer: "auth.example.com"}
+type JWTAuthenticator struct {
+	Secret    []byte
+	PublicKey *rsa.PublicKey
+
+	// Issuer and Audience are checked against the iss and aud claims when set
+	Issuer   string
+	Audience string
+	// Leeway allowed when checking exp and nbf
+	Leeway time.Duration
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+func (j *JWTAuthenticator) Authenticate(ctx Context) (*Principal, error) {
+	token, ok := authorization(ctx, "Bearer")
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	claims, err := j.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	sub, _ := claims["sub"].(string)
+	return &Principal{Subject: sub, Scheme: "Bearer", Claims: claims, Scopes: jwtScopes(claims)}, nil
+}
+
+func (j *JWTAuthenticator) Challenge() string {
+	return "Bearer"
+}
+
+// Verify checks the signature and the registered claims of a token and returns its claims
+func (j *JWTAuthenticator) Verify(token string) (map[string]interface{}, error) {
+	if len(j.Secret) == 0 && j.PublicKey == nil {
+		return nil, errors.New("the JWTAuthenticator has no Secret or PublicKey")
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidCredentials)
+	}
+	if err := j.verifySignature(header.Alg, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := j.validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (j *JWTAuthenticator) verifySignature(alg, signed string, signature []byte) error {
+	hash, ok := jwtHashes[alg]
+	if !ok {
+		return fmt.Errorf("%w: unsupported algorithm '%v'", ErrInvalidCredentials, alg)
+	}
+	switch {
+	case strings.HasPrefix(alg, "HS") && len(j.Secret) > 0:
+		mac := hmac.New(hash.New, j.Secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("%w: signature is invalid", ErrInvalidCredentials)
+		}
+	case strings.HasPrefix(alg, "RS") && j.PublicKey != nil:
+		h := hash.New()
+		h.Write([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(j.PublicKey, hash, h.Sum(nil), signature); err != nil {
+			return fmt.Errorf("%w: signature is invalid", ErrInvalidCredentials)
+		}
+	default:
+		return fmt.Errorf("%w: no key for algorithm '%v'", ErrInvalidCredentials, alg)
+	}
+	return nil
+}
+
+// validate checks exp, nbf, iss and aud
+func (j *JWTAuthenticator) validate(claims map[string]interface{}) error {
+	now := time.Now
+	if j.Now != nil {
+		now = j.Now
+	}
+	t := now()
+
+	if exp, ok := claims["exp"]; ok {
+		expires, err := claimTime(exp)
+		if err != nil {
+			return err
+		}
+		if t.After(expires.Add(j.Leeway)) {
+			return fmt.Errorf("%w: token is expired", ErrInvalidCredentials)
+		}
+	}
+	if nbf, ok := claims["nbf"]; ok {
+		notBefore, err := claimTime(nbf)
+		if err != nil {
+			return err
+		}
+		if t.Add(j.Leeway).Before(notBefore) {
+			return fmt.Errorf("%w: token is not valid yet", ErrInvalidCredentials)
+		}
+	}
+	if j.Issuer != "" && claims["iss"] != j.Issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidCredentials)
+	}
+	if j.Audience != "" && !hasAudience(claims["aud"], j.Audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+// decodeSegment decodes a base64url json segment, numbers are kept as json.Number
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+func claimTime(claim interface{}) (time.Time, error) {
+	n, ok := claim.(json.Number)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%w: time claims should be numbers", ErrInvalidCredentials)
+	}
+	seconds, err := n.Float64()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: time claims should be numbers", ErrInvalidCredentials)
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), nil
+}
+
+// hasAudience checks aud, which is either a string or an array of strings
+func hasAudience(aud interface{}, audience string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == audience
+	case []interface{}:
+		for _, a := range v {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
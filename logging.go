@@ -0,0 +1,127 @@
+package cuttle
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// Logger receives the structured request logs, args are alternating keys and values. *slog.Logger implements it.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// context key of the validation failures of a request
+const failuresKey = "cuttle.failures"
+
+// requestLog is the log entry of a request, filled while it's handled
+type requestLog struct {
+	method string
+	route  string
+	start  time.Time
+	params map[string]interface{}
+	// bound is when the params were resolved, handled when the handler returned
+	bound   time.Time
+	handled time.Time
+	// body counts the request body when its length isn't known up front
+	body       *countingBody
+	bodyLength int64
+}
+
+// countBody records the size of the request body, read lazily when the content length isn't set
+func (e *requestLog) countBody(req *http.Request) {
+	e.bodyLength = req.ContentLength
+	if req.ContentLength < 0 && req.Body != nil {
+		e.body = &countingBody{ReadCloser: req.Body}
+		req.Body = e.body
+	}
+}
+
+func (e *requestLog) bodySize() int64 {
+	if e.body != nil {
+		return e.body.n
+	}
+	return e.bodyLength
+}
+
+// responseStatus is the status of a request the handler returned err for, errors get the status echo responds with
+func responseStatus(ctx Context, err error) int {
+	status := ctx.Response().Status
+	if err != nil && !ctx.Response().Committed {
+		status = http.StatusInternalServerError
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			status = httpErr.Code
+		}
+	}
+	return status
+}
+
+// logRequest writes the entry once the handler returned err
+func (r *Cuttle) logRequest(entry *requestLog, ctx Context, status int, err error) {
+	args := []interface{}{
+		"request_id", GetRequestID(ctx),
+		"method", entry.method,
+		"route", entry.route,
+		"path", ctx.Request().URL.Path,
+		"status", status,
+	}
+	// the time spent in the handler, requests failing before it have none
+	if !entry.handled.IsZero() {
+		args = append(args, "duration", entry.handled.Sub(entry.bound))
+	}
+	if entry.params != nil {
+		args = append(args, "params", entry.params)
+	}
+	// failed validations are reported with their failures instead of the error
+	if failures, ok := ctx.Get(failuresKey).([]ValidationFail); ok {
+		args = append(args, "failures", failures)
+	} else if err != nil {
+		args = append(args, "error", err.Error())
+	}
+
+	switch {
+	case status >= 500:
+		r.RequestLogger.Error("request", args...)
+	case status >= 400:
+		r.RequestLogger.Warn("request", args...)
+	default:
+		r.RequestLogger.Info("request", args...)
+	}
+}
+
+// logParams returns the bound values of the handler's param structs, secrets are redacted
+func (p *routePlan) logParams(in *callArgs) map[string]interface{} {
+	params := map[string]interface{}{}
+	for i, arg := range p.args {
+		if arg.params == nil {
+			continue
+		}
+		v := in.in[i]
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		arg.params.logValues(v, "", params)
+	}
+	return params
+}
+
+func (p *structPlan) logValues(v reflect.Value, prefix string, params map[string]interface{}) {
+	for i := range p.fields {
+		f := &p.fields[i]
+		switch {
+		case f.nested != nil:
+			f.nested.logValues(v.Field(f.index), prefix+f.name, params)
+		case !f.logged:
+		case f.redact:
+			params[prefix+f.name] = redacted
+		default:
+			params[prefix+f.name] = v.Field(f.index).Interface()
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package cuttle
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type logEntry struct {
+	level string
+	msg   string
+	attrs map[string]interface{}
+}
+
+type recordingLogger struct {
+	entries []logEntry
+}
+
+func (l *recordingLogger) log(level, msg string, args []interface{}) {
+	attrs := map[string]interface{}{}
+	for i := 0; i+1 < len(args); i += 2 {
+		attrs[args[i].(string)] = args[i+1]
+	}
+	l.entries = append(l.entries, logEntry{level, msg, attrs})
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.log("debug", msg, args) }
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.log("info", msg, args) }
+func (l *recordingLogger) Warn(msg string, args ...interface{})  { l.log("warn", msg, args) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.log("error", msg, args) }
+
+func TestRequestLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	r := New()
+	r.RequestLogger = logger
+
+	type Filter struct {
+		Status string
+	}
+	r.GET("/users/:id", func(p struct {
+		ID     uint   `bind:"param"`
+		Token  string `bind:"header" as:"X-Token,secret"`
+		Limit  int    `as:"limit,required"`
+		Filter Filter
+		Ctx    Context
+	}) error {
+		if p.ID == 0 {
+			return echo.ErrNotFound
+		}
+		return p.Ctx.NoContent(http.StatusNoContent)
+	})
+
+	for _, tc := range []struct {
+		path   string
+		status int
+		level  string
+	}{
+		{"/users/1?limit=10&Status=open", 204, "info"},
+		{"/users/1", 400, "warn"},
+		{"/users/0?limit=1", 404, "warn"},
+	} {
+		request := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		request.Header.Set("X-Token", "hunter2")
+		r.ServeHTTP(httptest.NewRecorder(), request)
+
+		entry := logger.entries[len(logger.entries)-1]
+		assert.Equal(t, tc.level, entry.level, tc.path)
+		assert.Equal(t, "request", entry.msg)
+		assert.Equal(t, "GET", entry.attrs["method"])
+		assert.Equal(t, "/users/:id", entry.attrs["route"])
+		assert.Equal(t, tc.status, entry.attrs["status"], tc.path)
+		if tc.status == 400 {
+			assert.Nil(t, entry.attrs["duration"], tc.path)
+		} else {
+			assert.IsType(t, time.Duration(0), entry.attrs["duration"], tc.path)
+		}
+	}
+
+	assert.Len(t, logger.entries, 3)
+	assert.Equal(t, map[string]interface{}{"ID": uint(1), "Token": "[REDACTED]", "Limit": 10, "Filter.Status": "open"}, logger.entries[0].attrs["params"])
+	assert.Equal(t, []ValidationFail{{Field: "Limit", Err: ErrNoValueOnRequiredField.Error()}}, logger.entries[1].attrs["failures"])
+	assert.Nil(t, logger.entries[1].attrs["params"])
+	assert.Equal(t, "code=404, message=Not Found", logger.entries[2].attrs["error"])
+}
+
+func TestRequestLogger_HandlerDuration(t *testing.T) {
+	logger := &recordingLogger{}
+	r := New()
+	r.RequestLogger = logger
+	// slow to resolve, the time isn't the handler's
+	r.Provide(func(ctx Context) (*testDB, error) {
+		time.Sleep(100 * time.Millisecond)
+		return &testDB{}, nil
+	})
+	r.GET("/db", func(db *testDB, ctx Context) error {
+		return ctx.NoContent(http.StatusNoContent)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/db", nil))
+	assert.Len(t, logger.entries, 1)
+	assert.Less(t, logger.entries[0].attrs["duration"], 50*time.Millisecond)
+}
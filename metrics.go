@@ -0,0 +1,214 @@
+package cuttle
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// latency buckets in seconds, same as the prometheus client defaults
+	durationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// body size buckets in bytes
+	sizeBuckets = []float64{256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+)
+
+// Metrics records per route metrics of the routes registered with cuttle, exposed in the prometheus text format
+//
+//	r.EnableMetrics("/metrics")
+type Metrics struct {
+	mu       sync.Mutex
+	families []*family
+
+	requests         *family
+	bindDuration     *family
+	handlerDuration  *family
+	validationFailed *family
+	bodySize         *family
+}
+
+// NewMetrics returns empty metrics, set them as Cuttle.Metrics to record requests
+func NewMetrics() *Metrics {
+	m := &Metrics{}
+	m.requests = m.add("cuttle_requests_total", "Requests handled by cuttle routes.", "counter", nil, "method", "route", "status")
+	m.bindDuration = m.add("cuttle_bind_duration_seconds", "Time spent binding and validating the params.", "histogram", durationBuckets, "method", "route")
+	m.handlerDuration = m.add("cuttle_handler_duration_seconds", "Time spent in the handler.", "histogram", durationBuckets, "method", "route")
+	m.validationFailed = m.add("cuttle_validation_failures_total", "Validation failures by field.", "counter", nil, "method", "route", "field")
+	m.bodySize = m.add("cuttle_request_body_bytes", "Size of the request bodies.", "histogram", sizeBuckets, "method", "route")
+	return m
+}
+
+// EnableMetrics records the metrics of every cuttle route and serves them at path
+func (r *Cuttle) EnableMetrics(path string) *Metrics {
+	if r.Metrics == nil {
+		r.Metrics = NewMetrics()
+	}
+	r.Echo.GET(path, echo.WrapHandler(r.Metrics))
+	return r.Metrics
+}
+
+func (m *Metrics) add(name, help, typ string, buckets []float64, labels ...string) *family {
+	f := &family{name: name, help: help, typ: typ, labels: labels, buckets: buckets, series: map[string]*series{}}
+	m.families = append(m.families, f)
+	return f
+}
+
+// record adds a handled request
+func (m *Metrics) record(entry *requestLog, status int, failures []ValidationFail) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests.inc(entry.method, entry.route, strconv.Itoa(status))
+	if !entry.bound.IsZero() {
+		m.bindDuration.observe(entry.bound.Sub(entry.start).Seconds(), entry.method, entry.route)
+	}
+	if !entry.handled.IsZero() {
+		m.handlerDuration.observe(entry.handled.Sub(entry.bound).Seconds(), entry.method, entry.route)
+	}
+	for _, failure := range failures {
+		m.validationFailed.inc(entry.method, entry.route, failure.Field)
+	}
+	m.bodySize.observe(float64(entry.bodySize()), entry.method, entry.route)
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set(echo.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	buf := bufio.NewWriter(w)
+	cw := &countingWriter{w: buf}
+	for _, f := range m.families {
+		f.write(cw)
+	}
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, buf.Flush()
+}
+
+// family is a metric and its series by label values
+type family struct {
+	name, help, typ string
+	labels          []string
+	// buckets are the upper bounds of histograms
+	buckets []float64
+	series  map[string]*series
+}
+
+type series struct {
+	values []string
+	// count is the value of counters and the observation count of histograms
+	count   uint64
+	sum     float64
+	buckets []uint64
+}
+
+func (f *family) get(values []string) *series {
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: values, buckets: make([]uint64, len(f.buckets))}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *family) inc(values ...string) {
+	f.get(values).count++
+}
+
+func (f *family) observe(v float64, values ...string) {
+	s := f.get(values)
+	s.count++
+	s.sum += v
+	for i, bound := range f.buckets {
+		if v <= bound {
+			s.buckets[i]++
+		}
+	}
+}
+
+func (f *family) write(w io.Writer) {
+	if len(f.series) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n", f.name, f.help, f.name, f.typ)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := f.series[key]
+		labels := f.labelString(s.values)
+		if f.typ == "counter" {
+			fmt.Fprintf(w, "%v{%v} %v\n", f.name, labels, s.count)
+			continue
+		}
+		for i, bound := range f.buckets {
+			fmt.Fprintf(w, "%v_bucket{%v,le=\"%v\"} %v\n", f.name, labels, formatFloat(bound), s.buckets[i])
+		}
+		fmt.Fprintf(w, "%v_bucket{%v,le=\"+Inf\"} %v\n", f.name, labels, s.count)
+		fmt.Fprintf(w, "%v_sum{%v} %v\n", f.name, labels, formatFloat(s.sum))
+		fmt.Fprintf(w, "%v_count{%v} %v\n", f.name, labels, s.count)
+	}
+}
+
+func (f *family) labelString(values []string) string {
+	pairs := make([]string, len(values))
+	for i, v := range values {
+		pairs[i] = f.labels[i] + `="` + labelEscaper.Replace(v) + `"`
+	}
+	return strings.Join(pairs, ",")
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+// countingBody counts the bytes read from a request body, used when the content length isn't known
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingBody) Read(b []byte) (int, error) {
+	n, err := c.ReadCloser.Read(b)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,125 @@
+package cuttle
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	r := New()
+	r.EnableMetrics("/metrics")
+
+	r.GET("/users/:id", func(p struct {
+		ID    uint `bind:"param"`
+		Limit int  `as:"limit,required"`
+		Ctx   Context
+	}) error {
+		if p.ID == 0 {
+			return echo.ErrNotFound
+		}
+		return p.Ctx.NoContent(http.StatusNoContent)
+	})
+	r.POST("/users", func(p struct {
+		Body struct {
+			Name string `json:"name"`
+		} `bind:"body"`
+		Ctx Context
+	}) error {
+		return p.Ctx.NoContent(http.StatusCreated)
+	})
+
+	for _, path := range []string{"/users/1?limit=1", "/users/2?limit=1", "/users/0?limit=1", "/users/1"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	request := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"nokusu"}`))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	r.ServeHTTP(httptest.NewRecorder(), request)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE cuttle_requests_total counter",
+		`cuttle_requests_total{method="GET",route="/users/:id",status="204"} 2`,
+		`cuttle_requests_total{method="GET",route="/users/:id",status="404"} 1`,
+		`cuttle_requests_total{method="GET",route="/users/:id",status="400"} 1`,
+		`cuttle_requests_total{method="POST",route="/users",status="201"} 1`,
+		`cuttle_validation_failures_total{method="GET",route="/users/:id",field="Limit"} 1`,
+		"# TYPE cuttle_bind_duration_seconds histogram",
+		`cuttle_bind_duration_seconds_count{method="GET",route="/users/:id"} 4`,
+		`cuttle_handler_duration_seconds_bucket{method="GET",route="/users/:id",le="+Inf"} 3`,
+		`cuttle_handler_duration_seconds_count{method="GET",route="/users/:id"} 3`,
+		`cuttle_request_body_bytes_bucket{method="POST",route="/users",le="256"} 1`,
+		`cuttle_request_body_bytes_sum{method="POST",route="/users"} 17`,
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+	// the metrics endpoint itself isn't a cuttle route
+	assert.NotContains(t, body, `route="/metrics"`)
+}
+
+func TestMetrics_UnknownLength(t *testing.T) {
+	r := New()
+	r.Metrics = NewMetrics()
+	r.POST("/echo", func(p struct {
+		Body []byte `bind:"body"`
+		Ctx  Context
+	}) error {
+		return p.Ctx.Blob(http.StatusOK, echo.MIMEOctetStream, p.Body)
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("hello"))
+	request.ContentLength = -1
+	r.ServeHTTP(httptest.NewRecorder(), request)
+
+	var out strings.Builder
+	_, err := r.Metrics.WriteTo(&out)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), `cuttle_request_body_bytes_sum{method="POST",route="/echo"} 5`+"\n")
+	assert.Contains(t, out.String(), `cuttle_requests_total{method="POST",route="/echo",status="200"} 1`+"\n")
+}
+
+func TestMetrics_LabelEscaping(t *testing.T) {
+	m := NewMetrics()
+	m.validationFailed.inc("GET", "/", "a\"b\\c\nd")
+
+	var out strings.Builder
+	_, err := m.WriteTo(&out)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), `field="a\"b\\c\nd"`)
+	// families without series are left out
+	assert.NotContains(t, out.String(), "cuttle_requests_total")
+}
+
+// slowLogger takes a while to write each entry
+type slowLogger struct {
+	recordingLogger
+}
+
+func (l *slowLogger) Info(msg string, args ...interface{}) {
+	time.Sleep(100 * time.Millisecond)
+	l.log("info", msg, args)
+}
+
+func TestMetrics_HandlerDuration(t *testing.T) {
+	r := New()
+	r.RequestLogger = &slowLogger{}
+	r.EnableMetrics("/metrics")
+	r.GET("/fast", func(p struct{ Ctx Context }) error {
+		return p.Ctx.NoContent(http.StatusNoContent)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fast", nil))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	// the time spent logging the request isn't the handler's
+	assert.Contains(t, rec.Body.String(), `cuttle_handler_duration_seconds_bucket{method="GET",route="/fast",le="0.05"} 1`+"\n")
+}
@@ -0,0 +1,94 @@
+package cuttle
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"reflect"
+)
+
+// context key of the middleware chain added by Middleware
+const boundMiddlewareKey = "cuttle.boundmiddleware"
+
+// boundMiddleware runs with the resolved handler arguments
+type boundMiddleware func(ctx Context, args []reflect.Value, next func() error) error
+
+// Middleware returns echo middleware that runs fn after the handler's params are bound and validated, right before
+// the handler. p is the handler's param struct assignable to P, P can also be an interface the param structs
+// implement. Requests to handlers without such a param fail instead of skipping fn.
+//
+//	canAccessOrg := cuttle.Middleware(func(ctx cuttle.Context, p OrgScoped, next func() error) error {
+//	    if !canAccess(ctx, p.Org()) {
+//	        return echo.ErrForbidden
+//	    }
+//	    return next()
+//	})
+//	r.GET("/orgs/:org/users", listUsers, canAccessOrg)
+//
+// It only runs for routes registered with cuttle, plain echo handlers never call it.
+func Middleware[P any](fn func(ctx Context, p P, next func() error) error) MiddlewareFunc {
+	pType := reflect.TypeOf((*P)(nil)).Elem()
+	bound := func(ctx Context, args []reflect.Value, next func() error) error {
+		for _, arg := range args {
+			if p, ok := boundParams(arg, pType); ok {
+				return fn(ctx, p.Interface().(P), next)
+			}
+		}
+		return fmt.Errorf("middleware expects a %v param, the handler has none", pType)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			chain, _ := ctx.Get(boundMiddlewareKey).([]boundMiddleware)
+			ctx.Set(boundMiddlewareKey, append(chain, bound))
+			return next(ctx)
+		}
+	}
+}
+
+// boundParams returns arg as t if arg is a param struct, struct params are addressed for pointer types and
+// interfaces implemented with pointer receivers
+func boundParams(arg reflect.Value, t reflect.Type) (reflect.Value, bool) {
+	structType := arg.Type()
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	if arg.Type().AssignableTo(t) {
+		return arg, true
+	}
+	if arg.CanAddr() && arg.Addr().Type().AssignableTo(t) {
+		return arg.Addr(), true
+	}
+	return reflect.Value{}, false
+}
+
+var errNextCalled = errors.New("next called more than once")
+
+// run calls the handler through the middleware added by Middleware, in can't be used after.
+// The arguments go back to the pools once the whole chain returned, middleware can still read them after next.
+func (p *routePlan) run(ctx Context, in *callArgs) error {
+	defer p.release(in)
+	chain, _ := ctx.Get(boundMiddlewareKey).([]boundMiddleware)
+	if len(chain) == 0 {
+		return p.call(ctx, in)
+	}
+
+	called := false
+	var next func(i int) func() error
+	next = func(i int) func() error {
+		return func() error {
+			if i < len(chain) {
+				return chain[i](ctx, in.in, next(i+1))
+			}
+			if called {
+				return errNextCalled
+			}
+			called = true
+			return p.call(ctx, in)
+		}
+	}
+	return next(0)()
+}
@@ -0,0 +1,121 @@
+package cuttle
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type orgParams struct {
+	Org  uint `bind:"param"`
+	User string
+}
+
+func (p *orgParams) OrgID() uint {
+	return p.Org
+}
+
+func TestMiddleware_BoundParams(t *testing.T) {
+	r := New()
+	var calls []string
+	canAccessOrg := Middleware(func(ctx Context, p interface{ OrgID() uint }, next func() error) error {
+		calls = append(calls, "org")
+		if p.OrgID() != 1 {
+			return echo.ErrForbidden
+		}
+		return next()
+	})
+	defaultUser := Middleware(func(ctx Context, p *orgParams, next func() error) error {
+		calls = append(calls, "user")
+		if p.User == "" {
+			p.User = "joe"
+		}
+		return next()
+	})
+
+	r.GET("/orgs/:org", func(p orgParams, ctx Context) error {
+		calls = append(calls, "handler")
+		return ctx.String(http.StatusOK, p.User)
+	}, canAccessOrg, defaultUser)
+	r.GET("/users", func(ctx Context) error {
+		return nil
+	}, canAccessOrg)
+
+	for _, tc := range []struct {
+		path   string
+		code   int
+		expect string
+		calls  []string
+	}{
+		{"/orgs/1", 200, "joe", []string{"org", "user", "handler"}},
+		{"/orgs/1?User=mama", 200, "mama", []string{"org", "user", "handler"}},
+		{"/orgs/2", 403, "", []string{"org"}},
+		{"/users", 500, "", nil},
+	} {
+		calls = nil
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		assert.Equal(t, tc.code, rec.Code, tc.path)
+		if tc.expect != "" {
+			assert.Equal(t, tc.expect, rec.Body.String(), tc.path)
+		}
+		assert.Equal(t, tc.calls, calls, tc.path)
+	}
+}
+
+func TestMiddleware_AfterValidation(t *testing.T) {
+	r := New()
+	called := false
+	r.GET("/search", func(p struct {
+		Query string `as:"q,required"`
+	}) error {
+		return nil
+	}, Middleware(func(ctx Context, p interface{}, next func() error) error {
+		called = true
+		return next()
+	}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.False(t, called, "middleware should not run when validation fails")
+}
+
+func TestMiddleware_NextCalledTwice(t *testing.T) {
+	r := New()
+	calls := 0
+	r.GET("/twice", func(p struct{ Query string }) error {
+		calls++
+		return nil
+	}, Middleware(func(ctx Context, p interface{}, next func() error) error {
+		if err := next(); err != nil {
+			return err
+		}
+		return next()
+	}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/twice", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestMiddleware_AfterNext(t *testing.T) {
+	r := New()
+	var after uint
+	r.GET("/orgs/:org", func(p orgParams, ctx Context) error {
+		return ctx.NoContent(http.StatusNoContent)
+	}, Middleware(func(ctx Context, p *orgParams, next func() error) error {
+		err := next()
+		// the params are released once the whole chain returned
+		after = p.Org
+		return err
+	}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orgs/7", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, uint(7), after)
+}
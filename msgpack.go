@@ -0,0 +1,199 @@
+package cuttle
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// encodeMsgpack writes v as MessagePack, structs are written as maps keyed like encoding/json keys them and
+// TextMarshalers like time.Time as strings
+func encodeMsgpack(w io.Writer, v interface{}) error {
+	b, err := appendMsgpack(nil, reflect.ValueOf(v))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func appendMsgpack(b []byte, v reflect.Value) ([]byte, error) {
+	if !v.IsValid() {
+		return append(b, 0xc0), nil
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return append(b, 0xc0), nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return b, err
+		}
+		return appendMsgpackString(b, string(text)), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return appendMsgpack(b, v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendMsgpackInt(b, v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return appendMsgpackUint(b, v.Uint()), nil
+	case reflect.Float32:
+		return appendUint32(append(b, 0xca), math.Float32bits(float32(v.Float()))), nil
+	case reflect.Float64:
+		return appendUint64(append(b, 0xcb), math.Float64bits(v.Float())), nil
+	case reflect.String:
+		return appendMsgpackString(b, v.String()), nil
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Kind() == reflect.Slice && v.IsNil() {
+				return append(b, 0xc0), nil
+			}
+			return appendMsgpackBinary(b, v), nil
+		}
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return append(b, 0xc0), nil
+		}
+		b = appendMsgpackHeader(b, v.Len(), 0x90, 0xdc)
+		var err error
+		for i := 0; i < v.Len(); i++ {
+			if b, err = appendMsgpack(b, v.Index(i)); err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return append(b, 0xc0), nil
+		}
+		// keys are sorted so the same map is always written the same way
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		b = appendMsgpackHeader(b, len(keys), 0x80, 0xde)
+		var err error
+		for _, key := range keys {
+			if b, err = appendMsgpack(b, key); err != nil {
+				return b, err
+			}
+			if b, err = appendMsgpack(b, v.MapIndex(key)); err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	case reflect.Struct:
+		var fields []reflect.Value
+		var names []string
+		for _, f := range encodedFields(v.Type()) {
+			field := v.FieldByIndex(f.index)
+			if f.omitEmpty && field.IsZero() {
+				continue
+			}
+			fields = append(fields, field)
+			names = append(names, f.name)
+		}
+		b = appendMsgpackHeader(b, len(fields), 0x80, 0xde)
+		var err error
+		for i, field := range fields {
+			b = appendMsgpackString(b, names[i])
+			if b, err = appendMsgpack(b, field); err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	}
+	return b, fmt.Errorf("msgpack: unsupported type %v", v.Type())
+}
+
+func appendMsgpackInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0:
+		return appendMsgpackUint(b, uint64(n))
+	case n >= -32:
+		return append(b, byte(n))
+	case n >= math.MinInt8:
+		return append(b, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		return appendUint16(append(b, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		return appendUint32(append(b, 0xd2), uint32(n))
+	}
+	return appendUint64(append(b, 0xd3), uint64(n))
+}
+
+func appendMsgpackUint(b []byte, n uint64) []byte {
+	switch {
+	case n < 128:
+		return append(b, byte(n))
+	case n <= math.MaxUint8:
+		return append(b, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(b, 0xcd), uint16(n))
+	case n <= math.MaxUint32:
+		return appendUint32(append(b, 0xce), uint32(n))
+	}
+	return appendUint64(append(b, 0xcf), n)
+}
+
+func appendMsgpackString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = appendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = appendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+func appendMsgpackBinary(b []byte, v reflect.Value) []byte {
+	switch n := v.Len(); {
+	case n <= math.MaxUint8:
+		b = append(b, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		b = appendUint16(append(b, 0xc5), uint16(n))
+	default:
+		b = appendUint32(append(b, 0xc6), uint32(n))
+	}
+	for i := 0; i < v.Len(); i++ {
+		b = append(b, byte(v.Index(i).Uint()))
+	}
+	return b
+}
+
+// appendMsgpackHeader writes the length of an array or a map, fix is the fixarray or fixmap byte and long the
+// 16 bit header, the 32 bit one follows it
+func appendMsgpackHeader(b []byte, n int, fix, long byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(b, long), uint16(n))
+	}
+	return appendUint32(append(b, long+1), uint32(n))
+}
+
+func appendUint16(b []byte, n uint16) []byte {
+	return append(b, byte(n>>8), byte(n))
+}
+
+func appendUint32(b []byte, n uint32) []byte {
+	return append(b, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func appendUint64(b []byte, n uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(n>>32)), uint32(n))
+}
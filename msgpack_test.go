@@ -0,0 +1,70 @@
+package cuttle
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeMsgpack(t *testing.T) {
+	type embedded struct {
+		Tag string `json:"tag"`
+	}
+	type item struct {
+		embedded
+		ID     uint              `json:"id"`
+		Note   string            `json:"note,omitempty"`
+		Hidden string            `json:"-"`
+		Attrs  map[string]int8   `json:"attrs"`
+		When   time.Time         `json:"when"`
+		Raw    []byte            `json:"raw"`
+		Next   *item             `json:"next"`
+		Extra  map[string]string `json:"extra"`
+	}
+
+	for _, tc := range []struct {
+		v      interface{}
+		expect []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{false, []byte{0xc2}},
+		{5, []byte{0x05}},
+		{-5, []byte{0xfb}},
+		{200, []byte{0xcc, 0xc8}},
+		{-100, []byte{0xd0, 0x9c}},
+		{70000, []byte{0xce, 0x00, 0x01, 0x11, 0x70}},
+		{-40000, []byte{0xd2, 0xff, 0xff, 0x63, 0xc0}},
+		{uint64(1) << 40, []byte{0xcf, 0, 0, 0x01, 0, 0, 0, 0, 0}},
+		{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{float32(1.5), []byte{0xca, 0x3f, 0xc0, 0, 0}},
+		{"hi", []byte{0xa2, 'h', 'i'}},
+		{strings.Repeat("a", 40), append([]byte{0xd9, 40}, strings.Repeat("a", 40)...)},
+		{[]int{1, 2}, []byte{0x92, 0x01, 0x02}},
+		{[]string(nil), []byte{0xc0}},
+		{map[string]bool{"b": false, "a": true}, []byte{0x82, 0xa1, 'a', 0xc3, 0xa1, 'b', 0xc2}},
+		{make([]int, 20), append([]byte{0xdc, 0, 20}, make([]byte, 20)...)},
+		{
+			item{embedded: embedded{"x"}, ID: 1, Hidden: "no", Attrs: map[string]int8{"n": -1},
+				When: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), Raw: []byte{9}},
+			bytes.Join([][]byte{
+				{0x87},
+				{0xa3}, []byte("tag"), {0xa1, 'x'},
+				{0xa2}, []byte("id"), {0x01},
+				{0xa5}, []byte("attrs"), {0x81, 0xa1, 'n', 0xff},
+				{0xa4}, []byte("when"), {0xb4}, []byte("2021-01-02T03:04:05Z"),
+				{0xa3}, []byte("raw"), {0xc4, 0x01, 0x09},
+				{0xa4}, []byte("next"), {0xc0},
+				{0xa5}, []byte("extra"), {0xc0},
+			}, nil),
+		},
+	} {
+		var buf bytes.Buffer
+		assert.NoError(t, encodeMsgpack(&buf, tc.v))
+		assert.Equal(t, tc.expect, buf.Bytes(), "%#v", tc.v)
+	}
+
+	assert.Error(t, encodeMsgpack(&bytes.Buffer{}, make(chan int)))
+}
@@ -0,0 +1,355 @@
+package cuttle
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EncoderFunc writes v in the media type of its Encoder
+type EncoderFunc func(w io.Writer, v interface{}) error
+
+// Encoder writes responses of a media type, it's picked from the Accept header by Respond and typed handlers.
+//
+//	r.RegisterEncoder(cuttle.Encoder{MediaType: "text/yaml", Encode: func(w io.Writer, v interface{}) error { ... }})
+type Encoder struct {
+	MediaType string
+	// ContentType is written in the Content-Type header, MediaType is used when it's empty
+	ContentType string
+	// Encodes checks the encoder supports values of type t, nil supports every type
+	Encodes func(t reflect.Type) bool
+	Encode  EncoderFunc
+}
+
+func (e Encoder) encodes(t reflect.Type) bool {
+	return e.Encodes == nil || e.Encodes(t)
+}
+
+// defaultEncoders are the encoders of a new Cuttle, json is sent when any type is accepted
+func defaultEncoders() []Encoder {
+	return []Encoder{
+		{MediaType: echo.MIMEApplicationJSON, ContentType: echo.MIMEApplicationJSONCharsetUTF8, Encode: encodeJSON},
+		{MediaType: echo.MIMEApplicationXML, ContentType: echo.MIMEApplicationXMLCharsetUTF8, Encode: encodeXML},
+		{MediaType: echo.MIMEApplicationMsgpack, Encode: encodeMsgpack},
+		{MediaType: "application/x-msgpack", Encode: encodeMsgpack},
+		{MediaType: "text/csv", ContentType: "text/csv; charset=utf-8", Encodes: isStructSlice, Encode: encodeCSV},
+		{MediaType: echo.MIMETextPlain, ContentType: echo.MIMETextPlainCharsetUTF8, Encodes: isText, Encode: encodeText},
+	}
+}
+
+// RegisterEncoder adds an encoder for responses, an encoder already registered for the media type is replaced
+func (r *Cuttle) RegisterEncoder(encoder Encoder) {
+	if r.encoders == nil {
+		r.encoders = defaultEncoders()
+	}
+	for i := range r.encoders {
+		if strings.EqualFold(r.encoders[i].MediaType, encoder.MediaType) {
+			r.encoders[i] = encoder
+			return
+		}
+	}
+	r.encoders = append(r.encoders, encoder)
+}
+
+// Respond writes v with the encoder the Accept header prefers, it fails with a 406 when none is acceptable.
+// The response varies on Accept whatever the encoder is.
+//
+//	return r.Respond(ctx, http.StatusOK, users)
+func (r *Cuttle) Respond(ctx Context, code int, v interface{}) error {
+	ctx.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+	encoders := r.encoders
+	if encoders == nil {
+		encoders = defaultEncoders()
+	}
+	encoder, ok := negotiate(ctx.Request().Header.Get(echo.HeaderAccept), encoders, reflect.TypeOf(v))
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotAcceptable)
+	}
+
+	var buf bytes.Buffer
+	if err := encoder.Encode(&buf, v); err != nil {
+		return fmt.Errorf("failed to encode the response as %v: %w", encoder.MediaType, err)
+	}
+	contentType := encoder.ContentType
+	if contentType == "" {
+		contentType = encoder.MediaType
+	}
+	return ctx.Blob(code, contentType, buf.Bytes())
+}
+
+// mediaRange is a media range of an Accept header > text/*;q=0.5
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// specificity ranks ranges matching the same media type, type/subtype over type/* over */*
+func (m mediaRange) specificity() int {
+	switch {
+	case m.typ == "*":
+		return 0
+	case m.subtype == "*":
+		return 1
+	}
+	return 2
+}
+
+func (m mediaRange) matches(typ, subtype string) bool {
+	return (m.typ == "*" || m.typ == typ) && (m.subtype == "*" || m.subtype == subtype)
+}
+
+// parseAccept parses an Accept header, ranges with an invalid q-value are left out
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaType == "" {
+			continue
+		}
+		if mediaType == "*" {
+			mediaType = "*/*"
+		}
+		typeAndSub := strings.SplitN(mediaType, "/", 2)
+		if len(typeAndSub) != 2 {
+			continue
+		}
+		m := mediaRange{typ: typeAndSub[0], subtype: typeAndSub[1], q: 1}
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) != 2 || strings.ToLower(kv[0]) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil || q < 0 || q > 1 {
+				m.q = -1
+				break
+			}
+			m.q = q
+		}
+		if m.q >= 0 {
+			ranges = append(ranges, m)
+		}
+	}
+	return ranges
+}
+
+// negotiate picks the encoder of t with the highest q-value, ties go to the encoder matched by the more specific
+// range and then to the encoder registered first. The first encoder of t is picked without an Accept header.
+func negotiate(accept string, encoders []Encoder, t reflect.Type) (Encoder, bool) {
+	ranges := parseAccept(accept)
+	var best Encoder
+	bestQ, bestSpecificity, found := 0.0, -1, false
+	for _, encoder := range encoders {
+		if !encoder.encodes(t) {
+			continue
+		}
+		if len(ranges) == 0 {
+			return encoder, true
+		}
+		typeAndSub := strings.SplitN(strings.ToLower(encoder.MediaType), "/", 2)
+		if len(typeAndSub) != 2 {
+			continue
+		}
+		// the q-value of a media type is the one of the most specific range matching it
+		q, specificity := 0.0, -1
+		for _, m := range ranges {
+			if m.matches(typeAndSub[0], typeAndSub[1]) && m.specificity() > specificity {
+				q, specificity = m.q, m.specificity()
+			}
+		}
+		if q > 0 && (q > bestQ || (q == bestQ && specificity > bestSpecificity)) {
+			best, bestQ, bestSpecificity, found = encoder, q, specificity, true
+		}
+	}
+	return best, found
+}
+
+func encodeJSON(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func encodeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(v)
+}
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// isText checks values of t are written as plain text, scalars, byte slices, errors and Stringers are
+func isText(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if t.Implements(textMarshalerType) || t.Implements(stringerType) || t.Implements(errorType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return false
+}
+
+func encodeText(w io.Writer, v interface{}) error {
+	if b, ok := v.([]byte); ok {
+		_, err := w.Write(b)
+		return err
+	}
+	if err, ok := v.(error); ok {
+		_, werr := io.WriteString(w, err.Error())
+		return werr
+	}
+	s, err := textValue(reflect.ValueOf(v))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, s)
+	return err
+}
+
+// textValue formats a scalar for plain text and csv, nil pointers are empty
+func textValue(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		if v.Type().Implements(textMarshalerType) || v.Type().Implements(stringerType) {
+			break
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return "", nil
+	}
+	switch value := v.Interface().(type) {
+	case encoding.TextMarshaler:
+		text, err := value.MarshalText()
+		return string(text), err
+	case fmt.Stringer:
+		return value.String(), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	}
+	return fmt.Sprint(v.Interface()), nil
+}
+
+// isStructSlice checks values of t are written as csv, slices and arrays of structs or struct pointers are
+func isStructSlice(t reflect.Type) bool {
+	if t == nil || (t.Kind() != reflect.Slice && t.Kind() != reflect.Array) {
+		return false
+	}
+	elem := t.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct
+}
+
+// encodeCSV writes a header of the field names and a record per element, nil elements are left empty
+func encodeCSV(w io.Writer, v interface{}) error {
+	items := reflect.ValueOf(v)
+	elem := items.Type().Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	fields := encodedFields(elem)
+
+	out := csv.NewWriter(w)
+	record := make([]string, len(fields))
+	for i, f := range fields {
+		record[i] = f.name
+	}
+	if err := out.Write(record); err != nil {
+		return err
+	}
+	for i := 0; i < items.Len(); i++ {
+		item := items.Index(i)
+		for j, f := range fields {
+			record[j] = ""
+			if item.Kind() == reflect.Ptr && item.IsNil() {
+				continue
+			}
+			s, err := textValue(reflect.Indirect(item).FieldByIndex(f.index))
+			if err != nil {
+				return err
+			}
+			record[j] = s
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// encodedField is a struct field written by the csv and msgpack encoders
+type encodedField struct {
+	index     []int
+	name      string
+	omitEmpty bool
+}
+
+// encodedFields are the exported fields of t named like encoding/json names them, embedded structs without a
+// json name are flattened
+func encodedFields(t reflect.Type) []encodedField {
+	var fields []encodedField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		if tag[0] == "-" && len(tag) == 1 {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && tag[0] == "" {
+			for _, inner := range encodedFields(field.Type) {
+				inner.index = append([]int{i}, inner.index...)
+				fields = append(fields, inner)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		f := encodedField{index: []int{i}, name: field.Name}
+		if tag[0] != "" {
+			f.name = tag[0]
+		}
+		for _, option := range tag[1:] {
+			if option == "omitempty" {
+				f.omitEmpty = true
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
@@ -0,0 +1,130 @@
+package cuttle
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type negotiatedUser struct {
+	XMLName struct{}  `json:"-" xml:"user"`
+	ID      uint      `json:"id" xml:"id"`
+	Name    string    `json:"name" xml:"name"`
+	Joined  time.Time `json:"joined" xml:"joined"`
+	Email   *string   `json:"email,omitempty" xml:"email,omitempty"`
+}
+
+func TestNegotiate(t *testing.T) {
+	encoders := defaultEncoders()
+	users := reflect.TypeOf([]negotiatedUser{})
+	for _, tc := range []struct {
+		accept string
+		t      reflect.Type
+		expect string
+	}{
+		{"", users, "application/json"},
+		{"*/*", users, "application/json"},
+		{"text/csv", users, "text/csv"},
+		{"text/csv, */*", users, "text/csv"},
+		{"text/*, application/json;q=0.5", users, "text/csv"},
+		{"text/csv;q=0.4, application/xml;q=0.9", users, "application/xml"},
+		{"application/*;q=0.2, application/msgpack", users, "application/msgpack"},
+		{"application/x-msgpack", users, "application/x-msgpack"},
+		{"text/csv, application/json;q=0.5", reflect.TypeOf(negotiatedUser{}), "application/json"},
+		{"text/*", reflect.TypeOf(""), "text/plain"},
+		{"TEXT/Plain; charset=utf-8", reflect.TypeOf(1), "text/plain"},
+		{"application/json;q=x, text/plain", reflect.TypeOf(""), "text/plain"},
+		{"application/json;q=0, */*", users, "application/xml"},
+		{"*", users, "application/json"},
+	} {
+		encoder, ok := negotiate(tc.accept, encoders, tc.t)
+		assert.True(t, ok, tc.accept)
+		assert.Equal(t, tc.expect, encoder.MediaType, tc.accept)
+	}
+
+	for _, accept := range []string{"image/png", "text/csv;q=0", "image/*;q=0.5, text/plain"} {
+		_, ok := negotiate(accept, encoders, users)
+		assert.False(t, ok, accept)
+	}
+}
+
+func TestRespond(t *testing.T) {
+	r := New()
+	joined := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	email := "joe@example.com"
+	Get(r, "/users", func(ctx Context, p struct{}) ([]negotiatedUser, error) {
+		return []negotiatedUser{{ID: 1, Name: "joe, jr", Joined: joined, Email: &email}, {ID: 2, Name: "ann", Joined: joined}}, nil
+	})
+	Get(r, "/users/count", func(ctx Context, p struct{}) (int, error) {
+		return 2, nil
+	})
+
+	for _, tc := range []struct {
+		path, accept string
+		code         int
+		contentType  string
+		expect       string
+	}{
+		{"/users", "", 200, "application/json; charset=UTF-8",
+			`[{"id":1,"name":"joe, jr","joined":"2021-03-04T05:06:07Z","email":"joe@example.com"},{"id":2,"name":"ann","joined":"2021-03-04T05:06:07Z"}]` + "\n"},
+		{"/users", "text/csv", 200, "text/csv; charset=utf-8",
+			"id,name,joined,email\n1,\"joe, jr\",2021-03-04T05:06:07Z,joe@example.com\n2,ann,2021-03-04T05:06:07Z,\n"},
+		{"/users", "application/xml", 200, "application/xml; charset=UTF-8",
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<user><id>1</id><name>joe, jr</name><joined>2021-03-04T05:06:07Z</joined><email>joe@example.com</email></user>` +
+				`<user><id>2</id><name>ann</name><joined>2021-03-04T05:06:07Z</joined></user>`},
+		{"/users", "text/plain", 406, "application/json; charset=UTF-8", `{"message":"Not Acceptable","request_id":"negotiate"}` + "\n"},
+		{"/users/count", "text/plain, application/json;q=0.9", 200, "text/plain; charset=UTF-8", "2"},
+		{"/users/count", "text/csv", 406, "application/json; charset=UTF-8", `{"message":"Not Acceptable","request_id":"negotiate"}` + "\n"},
+	} {
+		request := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		request.Header.Set(HeaderRequestID, "negotiate")
+		if tc.accept != "" {
+			request.Header.Set("Accept", tc.accept)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, request)
+		assert.Equal(t, tc.code, rec.Code, tc.accept)
+		assert.Equal(t, tc.contentType, rec.Header().Get("Content-Type"), tc.accept)
+		assert.Equal(t, "Accept", rec.Header().Get("Vary"), tc.accept)
+		assert.Equal(t, tc.expect, rec.Body.String(), tc.accept)
+	}
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	r := New()
+	r.RegisterEncoder(Encoder{MediaType: "text/csv", Encode: func(w io.Writer, v interface{}) error {
+		_, err := io.WriteString(w, "replaced")
+		return err
+	}})
+	r.RegisterEncoder(Encoder{
+		MediaType: "text/x-shout",
+		Encodes:   isText,
+		Encode: func(w io.Writer, v interface{}) error {
+			_, err := io.WriteString(w, strings.ToUpper(v.(string)))
+			return err
+		},
+	})
+	Get(r, "/greeting", func(ctx Context, p struct{}) (string, error) {
+		return "hello", nil
+	})
+
+	for accept, expect := range map[string]string{
+		"text/csv":     "replaced",
+		"text/x-shout": "HELLO",
+		"":             `"hello"` + "\n",
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/greeting", nil)
+		request.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, request)
+		assert.Equal(t, http.StatusOK, rec.Code, accept)
+		assert.Equal(t, expect, rec.Body.String(), accept)
+	}
+	assert.Equal(t, "text/csv", r.encoders[4].MediaType)
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package cuttle
+
+const raceEnabled = false
@@ -0,0 +1,159 @@
+package cuttle
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Page is the page of a list endpoint, read from the page and per_page query params.
+// The page tag sets the default and max per_page, cursor pages read cursor instead of page.
+//
+//	Page cuttle.Page `page:"default=20,max=100"`
+//	Page cuttle.Page `page:"cursor,max=50"`
+type Page struct {
+	// Number is the requested page starting from 1, it's 0 for cursor pages
+	Number  int
+	PerPage int
+	Cursor  string
+	// key is the query param the page was read from, page or cursor with the prefix of the struct
+	key string
+}
+
+// queryKey returns the param the links of the page set, fallback for pages that weren't bound
+func (p Page) queryKey(fallback string) string {
+	if p.key == "" {
+		return fallback
+	}
+	return p.key
+}
+
+// Offset is the number of items before the page
+func (p Page) Offset() int {
+	if p.Number < 1 {
+		return 0
+	}
+	return (p.Number - 1) * p.PerPage
+}
+
+// Limit is the number of items in the page
+func (p Page) Limit() int {
+	return p.PerPage
+}
+
+var pageType = reflect.TypeOf(Page{})
+
+// pageOptions are the parsed page tag
+type pageOptions struct {
+	perPage, max int
+	cursor       bool
+}
+
+func parsePageTag(tag string) (pageOptions, error) {
+	opts := pageOptions{perPage: 20, max: 100}
+	if tag == "" {
+		return opts, nil
+	}
+	for _, option := range strings.Split(tag, ",") {
+		kv := strings.SplitN(option, "=", 2)
+		var err error
+		switch {
+		case kv[0] == "cursor" && len(kv) == 1:
+			opts.cursor = true
+		case kv[0] == "default" && len(kv) == 2:
+			opts.perPage, err = strconv.Atoi(kv[1])
+		case kv[0] == "max" && len(kv) == 2:
+			opts.max, err = strconv.Atoi(kv[1])
+		default:
+			return opts, fmt.Errorf("unknown page option '%v'", option)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("invalid page option '%v'", option)
+		}
+	}
+	if opts.perPage < 1 || opts.max < opts.perPage {
+		return opts, errors.New("the page default should be between 1 and max")
+	}
+	return opts, nil
+}
+
+// pageBinder reads the page params, their names are prefixed inside prefixed structs
+func pageBinder(prefix string, opts pageOptions) fieldBinder {
+	perPage, cursor, number := prefix+"per_page", prefix+"cursor", prefix+"page"
+	return func(ctx Context, field reflect.Value) error {
+		page := Page{PerPage: opts.perPage}
+		if s := ctx.QueryParam(perPage); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 {
+				return errors.New("per_page should be a positive number")
+			}
+			if n > opts.max {
+				return fmt.Errorf("per_page should be at most %v", opts.max)
+			}
+			page.PerPage = n
+		}
+
+		if opts.cursor {
+			page.key = cursor
+			page.Cursor = ctx.QueryParam(cursor)
+		} else {
+			page.key = number
+			page.Number = 1
+			if s := ctx.QueryParam(number); s != "" {
+				n, err := strconv.Atoi(s)
+				if err != nil || n < 1 {
+					return errors.New("page should be a positive number")
+				}
+				page.Number = n
+			}
+		}
+		field.Set(reflect.ValueOf(page))
+		return nil
+	}
+}
+
+// SetPageHeaders sets X-Total-Count and a Link header to the first, previous, next and last pages
+//
+//	users, total := store.List(p.Page.Offset(), p.Page.Limit())
+//	cuttle.SetPageHeaders(p.Ctx, p.Page, total)
+func SetPageHeaders(ctx Context, page Page, total int) {
+	header := ctx.Response().Header()
+	header.Set("X-Total-Count", strconv.Itoa(total))
+
+	last := 1
+	if page.PerPage > 0 && total > 0 {
+		last = (total + page.PerPage - 1) / page.PerPage
+	}
+	key := page.queryKey("page")
+	links := []string{pageLink(ctx, key, "1", "first")}
+	if page.Number > 1 {
+		links = append(links, pageLink(ctx, key, strconv.Itoa(page.Number-1), "prev"))
+	}
+	if page.Number < last {
+		links = append(links, pageLink(ctx, key, strconv.Itoa(page.Number+1), "next"))
+	}
+	links = append(links, pageLink(ctx, key, strconv.Itoa(last), "last"))
+	header.Set("Link", strings.Join(links, ", "))
+}
+
+// SetCursorHeaders sets a Link header to the next page of a cursor page, there's none when next is empty
+//
+//	cuttle.SetCursorHeaders(p.Ctx, p.Page, events.Next)
+func SetCursorHeaders(ctx Context, page Page, next string) {
+	if next == "" {
+		return
+	}
+	ctx.Response().Header().Set("Link", pageLink(ctx, page.queryKey("cursor"), next, "next"))
+}
+
+// pageLink links to the request url with key set to value
+func pageLink(ctx Context, key, value, rel string) string {
+	req := ctx.Request()
+	query := req.URL.Query()
+	query.Set(key, value)
+	u := url.URL{Scheme: ctx.Scheme(), Host: req.Host, Path: req.URL.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf(`<%v>; rel="%v"`, u.String(), rel)
+}
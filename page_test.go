@@ -0,0 +1,126 @@
+package cuttle
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPage(t *testing.T) {
+	r := New()
+	var got Page
+	r.GET("/users", func(p struct {
+		Page Page `page:"default=10,max=50"`
+		Ctx  Context
+	}) error {
+		got = p.Page
+		SetPageHeaders(p.Ctx, p.Page, 95)
+		return p.Ctx.NoContent(http.StatusOK)
+	})
+
+	for _, tc := range []struct {
+		query  string
+		status int
+		page   Page
+		offset int
+	}{
+		{"", http.StatusOK, Page{Number: 1, PerPage: 10}, 0},
+		{"?page=3&per_page=20", http.StatusOK, Page{Number: 3, PerPage: 20}, 40},
+		{"?per_page=51", http.StatusBadRequest, Page{}, 0},
+		{"?page=0", http.StatusBadRequest, Page{}, 0},
+		{"?page=x", http.StatusBadRequest, Page{}, 0},
+	} {
+		got = Page{}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users"+tc.query, nil))
+		assert.Equal(t, tc.status, rec.Code, tc.query)
+		assert.Equal(t, tc.page.Number, got.Number, tc.query)
+		assert.Equal(t, tc.page.PerPage, got.PerPage, tc.query)
+		assert.Equal(t, tc.offset, got.Offset(), tc.query)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?page=2&per_page=20&q=a", nil))
+	assert.Equal(t, "95", rec.Header().Get("X-Total-Count"))
+	assert.Equal(t, `<http://example.com/users?page=1&per_page=20&q=a>; rel="first", `+
+		`<http://example.com/users?page=1&per_page=20&q=a>; rel="prev", `+
+		`<http://example.com/users?page=3&per_page=20&q=a>; rel="next", `+
+		`<http://example.com/users?page=5&per_page=20&q=a>; rel="last"`, rec.Header().Get("Link"))
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?per_page=50", nil))
+	assert.Equal(t, `<http://example.com/users?page=1&per_page=50>; rel="first", `+
+		`<http://example.com/users?page=2&per_page=50>; rel="next", `+
+		`<http://example.com/users?page=2&per_page=50>; rel="last"`, rec.Header().Get("Link"))
+}
+
+func TestPage_Cursor(t *testing.T) {
+	r := New()
+	var got Page
+	r.GET("/events", func(p struct {
+		Page Page `page:"cursor"`
+		Ctx  Context
+	}) error {
+		got = p.Page
+		SetCursorHeaders(p.Ctx, p.Page, "c2")
+		return p.Ctx.NoContent(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events?cursor=c1&page=4", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, Page{PerPage: 20, Cursor: "c1", key: "cursor"}, got)
+	assert.Equal(t, `<http://example.com/events?cursor=c2&page=4>; rel="next"`, rec.Header().Get("Link"))
+}
+
+func TestPage_Prefix(t *testing.T) {
+	type CommentsQuery struct {
+		Page Page `page:"default=10"`
+	}
+	type EventsQuery struct {
+		Page Page `page:"cursor"`
+	}
+	r := New()
+	r.GET("/posts", func(p struct {
+		Comments CommentsQuery `prefix:"comments_"`
+		Ctx      Context
+	}) error {
+		SetPageHeaders(p.Ctx, p.Comments.Page, 25)
+		return p.Ctx.NoContent(http.StatusOK)
+	})
+	r.GET("/events", func(p struct {
+		Events EventsQuery `prefix:"events_"`
+		Ctx    Context
+	}) error {
+		SetCursorHeaders(p.Ctx, p.Events.Page, "c2")
+		return p.Ctx.NoContent(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts?comments_page=2", nil))
+	assert.Equal(t, `<http://example.com/posts?comments_page=1>; rel="first", `+
+		`<http://example.com/posts?comments_page=1>; rel="prev", `+
+		`<http://example.com/posts?comments_page=3>; rel="next", `+
+		`<http://example.com/posts?comments_page=3>; rel="last"`, rec.Header().Get("Link"))
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events?events_cursor=c1", nil))
+	assert.Equal(t, `<http://example.com/events?events_cursor=c2>; rel="next"`, rec.Header().Get("Link"))
+}
+
+func TestPage_InvalidTag(t *testing.T) {
+	r := New()
+	r.CollectErrors = true
+	r.GET("/users", func(p struct {
+		Page Page `page:"max=x"`
+	}) error {
+		return nil
+	})
+	assert.Contains(t, r.Check().Error(), "invalid page option 'max=x'")
+
+	for _, tag := range []string{"default=0", "default=50,max=10", "size=10", "cursor=1"} {
+		_, err := parsePageTag(tag)
+		assert.Error(t, err, tag)
+	}
+}
@@ -0,0 +1,157 @@
+package cuttle
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// named param constraints > /users/:id<int>
+var paramPatterns = map[string]string{
+	"int":   `[-+]?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"float": `[-+]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][-+]?[0-9]+)?`,
+	"bool":  `1|t|T|TRUE|true|True|0|f|F|FALSE|false|False`,
+	"alpha": `[a-zA-Z]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// constraints inferred from the type of the field bound to the param
+var kindPatterns = map[reflect.Kind]*regexp.Regexp{}
+
+func init() {
+	for name, kinds := range map[string][]reflect.Kind{
+		"int":   {reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64},
+		"uint":  {reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64},
+		"float": {reflect.Float32, reflect.Float64},
+		"bool":  {reflect.Bool},
+	} {
+		pattern := anchored(paramPatterns[name])
+		for _, kind := range kinds {
+			kindPatterns[kind] = pattern
+		}
+	}
+}
+
+func anchored(pattern string) *regexp.Regexp {
+	return regexp.MustCompile("^(?:" + pattern + ")$")
+}
+
+// pathParams are the `:name` segments of a route and their constraints, it also tracks which params are read by
+// the handler's fields
+type pathParams struct {
+	// route as registered > /users/:id<int>
+	route string
+	// path is the route without the constraints, registered with echo > /users/:id
+	path string
+	// names of the params in order, the wildcard is named *
+	names []string
+	// patterns are the constraints of the params by position, declared in the route or inferred from the field
+	// type, nil when the param matches anything
+	patterns []*regexp.Regexp
+	used     map[string]bool
+	// any is set when the handler gets the Context or a generated binder, it can read the params itself
+	any bool
+}
+
+// parsePathParams parses a route with optional param constraints, the constraint is either one of the named
+// patterns or a regular expression matched against the whole segment
+//
+//	/users/:id<int>
+//	/files/:name<[a-z0-9-]+>
+func parsePathParams(route string) (*pathParams, error) {
+	p := &pathParams{route: route, used: map[string]bool{}}
+	var path strings.Builder
+	for i := 0; i < len(route); i++ {
+		switch route[i] {
+		case '*':
+			p.names = append(p.names, "*")
+			p.patterns = append(p.patterns, nil)
+			path.WriteByte('*')
+		case ':':
+			start := i + 1
+			for i+1 < len(route) && route[i+1] != '/' && route[i+1] != '<' {
+				i++
+			}
+			name := route[start : i+1]
+			path.WriteString(":" + name)
+
+			var pattern *regexp.Regexp
+			if i+1 < len(route) && route[i+1] == '<' {
+				// the constraint ends at the matching >, regular expressions can have their own brackets
+				i++
+				begin, depth := i+1, 0
+				for ; i < len(route); i++ {
+					if route[i] == '<' {
+						depth++
+					}
+					if route[i] == '>' {
+						if depth--; depth == 0 {
+							break
+						}
+					}
+				}
+				if i == len(route) {
+					return nil, fmt.Errorf("constraint of path parameter '%v' is not closed", name)
+				}
+				constraint := route[begin:i]
+				if named, ok := paramPatterns[constraint]; ok {
+					constraint = named
+				}
+				var err error
+				if pattern, err = regexp.Compile("^(?:" + constraint + ")$"); err != nil {
+					return nil, fmt.Errorf("invalid constraint for path parameter '%v': %w", name, err)
+				}
+			}
+			p.names = append(p.names, name)
+			p.patterns = append(p.patterns, pattern)
+		default:
+			path.WriteByte(route[i])
+		}
+	}
+	p.path = path.String()
+	return p, nil
+}
+
+// read marks the param looked up by a field as used, the lookup falls back to the lowercase name like
+// ContextResolvers.Get. Params without a constraint get one from the field type. Returns false when the route
+// has no such segment.
+func (p *pathParams) read(name string, t reflect.Type) bool {
+	lower := strings.ToLower(name)
+	for i, param := range p.names {
+		if param != name && param != lower {
+			continue
+		}
+		p.used[param] = true
+		if p.patterns[i] == nil {
+			p.patterns[i] = kindPatterns[t.Kind()]
+		}
+		return true
+	}
+	return false
+}
+
+// unused returns the route params no field reads
+func (p *pathParams) unused() []string {
+	if p.any {
+		return nil
+	}
+	var unused []string
+	for _, param := range p.names {
+		if param != "*" && !p.used[param] {
+			unused = append(unused, param)
+		}
+	}
+	return unused
+}
+
+// match checks the param values of a request against the constraints
+func (p *pathParams) match(values []string) bool {
+	for i, pattern := range p.patterns {
+		if pattern != nil && (i >= len(values) || !pattern.MatchString(values[i])) {
+			return false
+		}
+	}
+	return true
+}
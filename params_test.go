@@ -0,0 +1,148 @@
+package cuttle
+
+import (
+	"bytes"
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestCuttle_PathParamMissing(t *testing.T) {
+	r := New()
+	assert.PanicsWithError(t, "invalid userHandler for GET /users/:user:\n\targ0.ID (uint): path parameter 'ID' is not in route '/users/:user'", func() {
+		r.GET("/users/:user", func(p struct {
+			ID uint `bind:"param"`
+		}) error {
+			return nil
+		})
+	})
+
+	r.CollectErrors = true
+	type Owner struct {
+		Org string `bind:"param" as:"org"`
+	}
+	r.GET("/repos/:repo", func(p struct {
+		Repo  string `bind:"param"`
+		Owner Owner
+	}) error {
+		return nil
+	})
+	err := r.Check()
+	assert.EqualError(t, err, "invalid userHandler for GET /repos/:repo:\n\targ0.Owner.Org (string): path parameter 'org' is not in route '/repos/:repo'")
+}
+
+func TestCuttle_PathParamBound(t *testing.T) {
+	r := New()
+	var got uint
+	r.GET("/users/:id", func(p struct {
+		ID uint `bind:"param"`
+	}) error {
+		got = p.ID
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/7", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, uint(7), got)
+}
+
+func TestCuttle_PathParamUnused(t *testing.T) {
+	r := New()
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stdout)
+
+	r.GET("/orgs/:org/users/:id", func(p struct {
+		ID uint
+	}) error {
+		return nil
+	})
+	assert.Contains(t, logs.String(), "GET /orgs/:org/users/:id: path parameter 'org' is not bound by any field")
+	assert.NotContains(t, logs.String(), "'id'")
+
+	// the handler can read the params from the context
+	logs.Reset()
+	r.GET("/teams/:team", func(p struct{}, ctx Context) error {
+		return nil
+	})
+	assert.NotContains(t, logs.String(), "not bound by any field")
+}
+
+func TestCuttle_PathParamConstraints(t *testing.T) {
+	r := New()
+	r.GET("/users/:id<int>", func(p struct {
+		ID  string `bind:"param"`
+		Ctx Context
+	}) error {
+		return p.Ctx.String(http.StatusOK, "id "+p.ID)
+	})
+	r.GET("/users/:name<[a-z0-9-]+>", func(p struct {
+		Name string `bind:"param"`
+		Ctx  Context
+	}) error {
+		return p.Ctx.String(http.StatusOK, "name "+p.Name)
+	})
+	// constraint inferred from the field type
+	r.GET("/orders/:id", func(p struct {
+		ID  uint `bind:"param"`
+		Ctx Context
+	}) error {
+		return p.Ctx.String(http.StatusOK, "order")
+	})
+	r.GET("/files/*", func(ctx Context) error {
+		return ctx.String(http.StatusOK, "file "+ctx.Param("*"))
+	})
+
+	for _, tc := range []struct {
+		path   string
+		code   int
+		expect string
+	}{
+		{"/users/42", 200, "id 42"},
+		{"/users/-42", 200, "id -42"},
+		{"/users/joe-1", 200, "name joe-1"},
+		{"/users/Joe", 404, ""},
+		{"/orders/7", 200, "order"},
+		{"/orders/abc", 404, ""},
+		{"/files/a/b.txt", 200, "file a/b.txt"},
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		assert.Equal(t, tc.code, rec.Code, tc.path)
+		if tc.expect != "" {
+			assert.Equal(t, tc.expect, rec.Body.String(), tc.path)
+		}
+	}
+}
+
+func TestCuttle_PathParamConstraintReplaced(t *testing.T) {
+	r := New()
+	for _, body := range []string{"first", "second"} {
+		body := body
+		r.GET("/items/:id<uint>", func(ctx Context) error {
+			return ctx.String(http.StatusOK, body)
+		})
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+	assert.Equal(t, "second", rec.Body.String())
+	assert.Len(t, r.Routes(), 1)
+}
+
+func TestCuttle_PathParamInvalidConstraint(t *testing.T) {
+	r := New()
+	assert.PanicsWithError(t, "invalid userHandler for GET /users/:id<int:\n\tpath: constraint of path parameter 'id' is not closed", func() {
+		r.GET("/users/:id<int", func(ctx Context) error {
+			return nil
+		})
+	})
+	assert.Panics(t, func() {
+		r.GET("/users/:id<[a-z>", func(ctx Context) error {
+			return nil
+		})
+	})
+}
@@ -0,0 +1,546 @@
+package cuttle
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// debugEnabled guards the request time debug logs, avoids building the variadic args when the logs are dropped anyway
+func debugEnabled() bool {
+	return log.Level() <= log.DEBUG
+}
+
+// routePlan is the binding pipeline of a route, compiled once when the route is registered
+type routePlan struct {
+	handler reflect.Value
+	args    []argPlan
+	// route is the parsed path, holds the param constraints
+	route *pathParams
+	// scopes required by the Scopes markers of the params, checked by authorize before binding
+	scopes    []string
+	authorize func(ctx Context) error
+	// events writes the events of handlers returning (<-chan Event, error)
+	events func(ctx Context, events <-chan Event) error
+	// pooled *callArgs
+	vals sync.Pool
+}
+
+// callArgs holds the resolved arguments of a request
+type callArgs struct {
+	in []reflect.Value
+}
+
+type argPlan struct {
+	// resolve returns the value passed to the handler
+	//   |			validation passed
+	//   |           |     unhandled error
+	resolve func(ctx Context) (reflect.Value, bool, error)
+	// release is called after the handler returns, used to return pooled values
+	release func(reflect.Value)
+	// params is the compiled struct of bound params, used to log their values
+	params *structPlan
+}
+
+// resolve returns the arguments passed to the userHandler
+func (p *routePlan) resolve(ctx Context) (*callArgs, error) {
+	if p.authorize != nil {
+		if err := p.authorize(ctx); err != nil {
+			return nil, err
+		}
+	}
+	vals := p.getVals()
+	for i := range p.args {
+		value, ok, err := p.args[i].resolve(ctx)
+		if !ok {
+			p.release(vals)
+			return nil, fmt.Errorf("request validation failed")
+		}
+		if err != nil {
+			p.release(vals)
+			return nil, err
+		}
+		vals.in[i] = value
+	}
+	return vals, nil
+}
+
+func (p *routePlan) getVals() *callArgs {
+	if vals, ok := p.vals.Get().(*callArgs); ok {
+		return vals
+	}
+	return &callArgs{in: make([]reflect.Value, len(p.args))}
+}
+
+// release returns the resolved arguments and their holder to the pools
+func (p *routePlan) release(vals *callArgs) {
+	for i, val := range vals.in {
+		if val.IsValid() && p.args[i].release != nil {
+			p.args[i].release(val)
+		}
+		vals.in[i] = reflect.Value{}
+	}
+	p.vals.Put(vals)
+}
+
+// call runs the handler with the resolved arguments, run releases them
+func (p *routePlan) call(ctx Context, in *callArgs) error {
+	retVal := p.handler.Call(in.in)
+	if p.events != nil {
+		// the goroutine sending the events can still read the params, they're released after the stream ends
+		if !retVal[1].IsNil() {
+			return retVal[1].Interface().(error)
+		}
+		return p.events(ctx, retVal[0].Interface().(<-chan Event))
+	}
+	if retVal[0].IsNil() {
+		return nil
+	}
+	return retVal[0].Interface().(error)
+}
+
+// fieldBinder sets a struct field from the request
+type fieldBinder func(ctx Context, field reflect.Value) error
+
+type fieldPlan struct {
+	index int
+	// name is reported in ValidationFail
+	name string
+	bind fieldBinder
+	// redact is set for secret values and the context, which holds the raw request, they're left out of the
+	// debug logs
+	redact bool
+	// logged is set for the scalar fields included in the request logs
+	logged bool
+	// nested is set for struct fields, their failures are reported with the field name as prefix
+	nested *structPlan
+	// items is set for slices of structs bound from indexed query keys
+	items *itemsPlan
+}
+
+// structPlan binds the fields of a struct, compiled from the struct tags
+type structPlan struct {
+	t         reflect.Type
+	zero      reflect.Value
+	fields    []fieldPlan
+	streaming bool
+	// binder is set when the struct has a generated Binder, fields is empty then
+	binder bool
+	// scopes of the Scopes markers, nested structs included
+	scopes []string
+	// requestIDs adds the request id to validation failures, set from the router's RequestIDs
+	requestIDs bool
+	pool       sync.Pool
+}
+
+// bind sets the fields of dst, validation failures are appended to failures while echo.HTTPErrors stop the binding
+func (p *structPlan) bind(ctx Context, dst reflect.Value, prefix string, failures []ValidationFail) ([]ValidationFail, error) {
+	// read the scalar form values ahead of the file parts
+	if p.streaming {
+		beginPartStream(ctx)
+	}
+
+	for i := range p.fields {
+		f := &p.fields[i]
+		field := dst.Field(f.index)
+		if f.nested != nil {
+			var err error
+			failures, err = f.nested.bind(ctx, field, prefix+f.name, failures)
+			if err != nil {
+				return failures, err
+			}
+			continue
+		}
+		if f.items != nil {
+			var err error
+			failures, err = f.items.bind(ctx, field, prefix+f.name, failures)
+			if err != nil {
+				return failures, err
+			}
+			continue
+		}
+
+		err := f.bind(ctx, field)
+		if err == nil {
+			if debugEnabled() {
+				var value interface{} = redacted
+				if !f.redact {
+					value = field.Interface()
+				}
+				log.Debug("[DEBUG] Setting struct value ", prefix+f.name, " ", value)
+			}
+			continue
+		}
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			return failures, err
+		}
+		failures = append(failures, ValidationFail{
+			Field: prefix + f.name,
+			Err:   err.Error(),
+		})
+		if debugEnabled() {
+			log.Debug("Validation failed", failures[len(failures)-1])
+		}
+	}
+	return failures, nil
+}
+
+// get returns a zeroed pointer to the struct from the pool
+func (p *structPlan) get() reflect.Value {
+	if v := p.pool.Get(); v != nil {
+		return reflect.ValueOf(v)
+	}
+	return reflect.New(p.t)
+}
+
+// put zeroes the struct and returns it to the pool
+func (p *structPlan) put(ptr reflect.Value) {
+	ptr.Elem().Set(p.zero)
+	p.pool.Put(ptr.Interface())
+}
+
+// argument returns the input resolver for a bound struct, pointer params are allocated for every request since the
+// handler can keep them around, value params are copied by reflect.Call so they're pooled.
+func (p *structPlan) argument(isPtr bool) argPlan {
+	plan := argPlan{
+		params: p,
+		resolve: func(ctx Context) (reflect.Value, bool, error) {
+			var in reflect.Value
+			if isPtr {
+				in = reflect.New(p.t)
+			} else {
+				in = p.get()
+			}
+
+			var failures []ValidationFail
+			var err error
+			if p.binder {
+				failures, err = in.Interface().(Binder).Bind(ctx)
+			} else {
+				failures, err = p.bind(ctx, in.Elem(), "", nil)
+			}
+			if err == nil && len(failures) != 0 {
+				if debugEnabled() {
+					log.Debug("Validation failed for this request")
+				}
+				ctx.Set(failuresKey, failures)
+				body := map[string]interface{}{
+					"message": "validation failed",
+					"fields":  failures,
+				}
+				if p.requestIDs {
+					body["request_id"] = GetRequestID(ctx)
+				}
+				err = ctx.JSON(http.StatusBadRequest, body)
+				if !isPtr {
+					p.put(in)
+				}
+				return reflect.Value{}, false, err
+			}
+			if err != nil {
+				if !isPtr {
+					p.put(in)
+				}
+				return reflect.Value{}, true, err
+			}
+
+			if isPtr {
+				return in, true, nil
+			}
+			return in.Elem(), true, nil
+		},
+	}
+	if !isPtr {
+		plan.release = func(v reflect.Value) {
+			p.put(v.Addr())
+		}
+	}
+	return plan
+}
+
+// lookupKey is the name looked up from the request, the lowercase fallback is computed ahead of the request
+type lookupKey struct {
+	name  string
+	lower string
+}
+
+func newLookupKey(name string) lookupKey {
+	return lookupKey{name: name, lower: strings.ToLower(name)}
+}
+
+// setter adapts resolvers that return the whole value, used for the less common field types
+func setter(resolver ResolverFunc) fieldBinder {
+	return func(ctx Context, field reflect.Value) error {
+		value, err := resolver(ctx)
+		if err != nil {
+			return err
+		}
+		if value != nil {
+			field.Set(reflect.ValueOf(value))
+		}
+		return nil
+	}
+}
+
+// scalarBinder returns the direct setter for string, bool and numeric kinds, nil if the kind isn't a scalar
+func scalarBinder(t reflect.Type, source BindSource) fieldBinder {
+	switch t.Kind() {
+	case reflect.String:
+		return func(ctx Context, field reflect.Value) error {
+			val, err := source.String(ctx)
+			if err != nil {
+				return err
+			}
+			field.SetString(val)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := t.Bits()
+		return func(ctx Context, field reflect.Value) error {
+			val, err := source.Int(ctx, bits)
+			if err != nil {
+				return err
+			}
+			field.SetInt(val)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		bits := t.Bits()
+		return func(ctx Context, field reflect.Value) error {
+			val, err := source.Uint(ctx, bits)
+			if err != nil {
+				return err
+			}
+			field.SetUint(val)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		bits := t.Bits()
+		return func(ctx Context, field reflect.Value) error {
+			val, err := source.Float(ctx, bits)
+			if err != nil {
+				return err
+			}
+			field.SetFloat(val)
+			return nil
+		}
+	case reflect.Bool:
+		return func(ctx Context, field reflect.Value) error {
+			val, err := source.Bool(ctx)
+			if err != nil {
+				return err
+			}
+			field.SetBool(val)
+			return nil
+		}
+	}
+	return nil
+}
+
+var echoContextType = reflect.TypeOf((*echo.Context)(nil)).Elem()
+
+// contextValue returns ctx as a value of the interface type t, reflect checks if the concrete type implements
+// the interface on every Call or Set otherwise
+func contextValue(ctx Context, t reflect.Type) reflect.Value {
+	switch t {
+	case cutleContextType:
+		return reflect.ValueOf(&ctx).Elem()
+	case echoContextType:
+		var c echo.Context = ctx
+		return reflect.ValueOf(&c).Elem()
+	}
+	return reflect.ValueOf(ctx)
+}
+
+func bindContext(ctx Context, field reflect.Value) error {
+	field.Set(contextValue(ctx, field.Type()))
+	return nil
+}
+
+// compileStruct only gets called on initialization of the handler, not during the request,
+// unsupported fields and `bind:"param"` fields missing from the route are reported to check with their path.
+// prefix is added to the names the fields are read by, see the prefix tag.
+func (r *Cuttle) compileStruct(inT reflect.Type, path, prefix string, params *pathParams, check *HandlerError) *structPlan {
+	plan := &structPlan{
+		t:          inT,
+		zero:       reflect.Zero(inT),
+		requestIDs: r.RequestIDs,
+	}
+	// iterate through struct fields
+	for i := 0; i < inT.NumField(); i++ {
+		field := inT.Field(i)
+		structTag := field.Tag
+		fieldPath := path + "." + field.Name
+
+		tags, err := parseFieldTags(field.Name, structTag)
+		if err != nil {
+			check.add(fieldPath, field.Type, err.Error())
+		}
+		tags.name = prefix + tags.name
+		log.Debug("[DEBUG] field info:", tags.name, structTag)
+
+		// required scopes > _ cuttle.Scopes `scopes:"orders:write"`
+		if field.Type == scopesType {
+			plan.scopes = append(plan.scopes, parseScopes(structTag)...)
+			continue
+		}
+
+		// skip unexported fields
+		if !field.IsExported() {
+			continue
+		}
+
+		// Checks for > Field Type `return:"200"`, value will be the http status code
+		if _, ok := structTag.Lookup("return"); ok {
+			log.Debug("[DEBUG] field is a return", field)
+			// TODO: handle return fields here
+			continue
+		}
+
+		fp := fieldPlan{index: i, name: field.Name, redact: tags.option.Secret}
+		// scalar fields, their type constrains the path param they read
+		scalarType := field.Type
+		bodyRes := r.bodyResolver(field.Type, tags)
+		switch {
+		// request body > Body []byte `as:",maxbytes=1MB"`, Body string `bind:"body"`
+		case bodyRes != nil:
+			log.Debug("[DEBUG] assigning field as body:", field.Name)
+			fp.bind = setter(bodyRes)
+		// > Sig []byte `bind:"header"` would silently get the body
+		case isBodyType(field.Type):
+			check.add(fieldPath, field.Type, "can only be bound to the body, add body to the bind tag")
+			continue
+		// streamed multipart files > Upload cuttle.PartStream
+		case isPartStream(field.Type):
+			log.Debug("[DEBUG] assigning field as part stream:", field.Name)
+			fp.bind = setter(partStreamResolver)
+		// multipart files > Avatar *multipart.FileHeader `as:"avatar,maxsize=1MB,accept=image/png,image/jpeg"`
+		case isFileType(field.Type):
+			log.Debug("[DEBUG] assigning field as file:", field.Name)
+			fp.bind = setter(fileResolver(field.Type, tags.name, tags.option))
+		// Set as echo.context here
+		case field.Type.ConvertibleTo(cutleContextType):
+			log.Debug("[DEBUG] Field", field.Type, "implements", cutleContextType)
+			fp.bind = bindContext
+			fp.redact = true
+			params.any = true
+		// authenticated caller > User cuttle.Principal, User *cuttle.Principal
+		case field.Type == principalType || field.Type == reflect.PtrTo(principalType):
+			if r.Authenticator == nil {
+				check.add(fieldPath, field.Type, "no Authenticator is set")
+				continue
+			}
+			fp.bind = r.principalBinder(field.Type.Kind() == reflect.Ptr)
+		// id of the request > ID cuttle.RequestID
+		case field.Type == requestIDType:
+			fp.bind = bindRequestID
+		// id of the last event a reconnecting client got > Since cuttle.LastEventID
+		case field.Type == lastEventIDType:
+			fp.bind = bindLastEventID
+		// list params > Page cuttle.Page `page:"max=100"`, Sort cuttle.Sort `sort:"name"`, Filter cuttle.Filter `filter:"status"`
+		case field.Type == pageType:
+			opts, err := parsePageTag(structTag.Get("page"))
+			if err != nil {
+				check.add(fieldPath, field.Type, err.Error())
+				continue
+			}
+			fp.bind = pageBinder(prefix, opts)
+			fp.logged = true
+		case field.Type == sortType:
+			allowed, err := parseSortTag(structTag.Get("sort"))
+			if err != nil {
+				check.add(fieldPath, field.Type, err.Error())
+				continue
+			}
+			fp.bind = sortBinder(prefix+listParamName(structTag, "sort"), allowed)
+			fp.logged = true
+		case field.Type == filterType:
+			allowed, err := parseFilterTag(structTag.Get("filter"))
+			if err != nil {
+				check.add(fieldPath, field.Type, err.Error())
+				continue
+			}
+			fp.bind = filterBinder(prefix+listParamName(structTag, "filter"), allowed)
+			fp.logged = true
+		// secret values > Token cuttle.Secret[string] `bind:"header" as:"X-Token"`
+		case isSecret(field.Type):
+			tags.option.Secret = true
+			fp.redact = true
+			fp.bind = secretBinder(field.Type, tags.source())
+			scalarType = reflect.New(field.Type).Interface().(secretField).secretType()
+		// deep objects > Meta map[string]string `as:"meta"` reads meta[color]=red
+		case field.Type.Kind() == reflect.Map:
+			sources := []string{"query"}
+			if bind, ok := structTag.Lookup("bind"); ok {
+				sources = strings.Split(bind, ",")
+			}
+			fp.bind = mapBinder(field.Type, tags, sources)
+			fp.logged = !tags.option.Secret
+		// indexed structs > Items []Item `as:"items"` reads items[0].id=1
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			elem := r.compileStruct(field.Type.Elem(), fieldPath+"[]", "", &pathParams{}, check)
+			checkItemFields(field.Type.Elem(), fieldPath+"[]", check)
+			max := tags.option.MaxCount
+			if max == 0 {
+				max = defaultMaxItems
+			}
+			fp.items = &itemsPlan{elem: elem, names: deepObjectNames(tags), max: max, required: tags.option.Required}
+			fp.logged = !elem.redacts()
+		case field.Type.Kind() == reflect.Struct:
+			// reusable fragments > Owner UserRef `prefix:"owner_"` reads owner_id
+			fragment, err := parsePrefixTag(structTag)
+			if err != nil {
+				check.add(fieldPath, field.Type, err.Error())
+				continue
+			}
+			fp.nested = r.compileStruct(field.Type, fieldPath, prefix+fragment.prefix, params, check)
+			plan.scopes = append(plan.scopes, fp.nested.scopes...)
+			// embedded and inline structs report their fields with the prefix instead of the field name
+			if field.Anonymous || fragment.inline {
+				fp.name = fragment.prefix
+			} else {
+				fp.name += "."
+			}
+		default:
+			// handles coercion from webRequest to the field type
+			fp.bind = scalarBinder(field.Type, tags.source())
+			// jwt claims > OrgID uint `bind:"claims" as:"org_id"`, Roles []string `bind:"claims"`
+			if tags.bindClaims {
+				if r.Authenticator == nil {
+					check.add(fieldPath, field.Type, "no Authenticator is set")
+					continue
+				}
+				fp.bind = r.claimsBinder(field.Type, tags.name, fp.bind)
+			}
+		}
+
+		if fp.bind == nil && fp.nested == nil && fp.items == nil {
+			check.add(fieldPath, field.Type, "unsupported field type")
+			continue
+		}
+		if fp.nested == nil && scalarBinder(scalarType, BindSource{}) != nil {
+			fp.logged = true
+			switch {
+			case tags.bindParam && !params.read(tags.name, scalarType):
+				check.add(fieldPath, field.Type, "path parameter '%v' is not in route '%v'", tags.name, params.route)
+			case tags.defaultBind:
+				params.read(tags.name, scalarType)
+			}
+		}
+		plan.fields = append(plan.fields, fp)
+	}
+
+	plan.streaming = containsField(inT, isPartStream)
+	return plan
+}
+
+// bindsNothing is set for structs without fields or scopes, like services missing their provider
+func (p *structPlan) bindsNothing() bool {
+	return len(p.fields) == 0 && len(p.scopes) == 0
+}
@@ -0,0 +1,102 @@
+package cuttle
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+type benchParams struct {
+	ID    uint    `bind:"param"`
+	Query string  `as:"q,required"`
+	Count int     `bind:"query"`
+	Ratio float64 `bind:"query"`
+	Token string  `bind:"header" as:"X-Token,sensitive"`
+}
+
+func benchRouters() (*Cuttle, *echo.Echo) {
+	r := New()
+	// generating the request id costs the same with or without binding
+	r.RequestIDs = false
+	r.GET("/bench/:id", func(params benchParams, ctx Context) error {
+		return ctx.NoContent(200)
+	})
+
+	e := echo.New()
+	e.GET("/bench/:id", func(ctx echo.Context) error {
+		var params benchParams
+		id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+		if err != nil {
+			return err
+		}
+		params.ID = uint(id)
+		params.Query = ctx.QueryParam("q")
+		if params.Count, err = strconv.Atoi(ctx.QueryParam("Count")); err != nil {
+			return err
+		}
+		if params.Ratio, err = strconv.ParseFloat(ctx.QueryParam("Ratio"), 64); err != nil {
+			return err
+		}
+		params.Token = ctx.Request().Header.Get("X-Token")
+		_ = params
+		return ctx.NoContent(200)
+	})
+	return r, e
+}
+
+func benchRequest() *http.Request {
+	request := httptest.NewRequest("GET", "/bench/10?q=cuttle&Count=3&Ratio=0.5", nil)
+	request.Header.Set("X-Token", "token")
+	return request
+}
+
+func benchmarkHandler(b *testing.B, h http.Handler) {
+	level := log.Level()
+	log.SetLevel(log.OFF)
+	defer log.SetLevel(level)
+
+	request := benchRequest()
+	w := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.ServeHTTP(w, request)
+	}
+}
+
+func BenchmarkCuttle_Bind(b *testing.B) {
+	r, _ := benchRouters()
+	benchmarkHandler(b, r)
+}
+
+func BenchmarkEcho_Plain(b *testing.B) {
+	_, e := benchRouters()
+	benchmarkHandler(b, e)
+}
+
+func TestPlan_Allocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations can't be counted under the race detector")
+	}
+	level := log.Level()
+	log.SetLevel(log.OFF)
+	defer log.SetLevel(level)
+
+	r, e := benchRouters()
+	allocs := func(h http.Handler) float64 {
+		request := benchRequest()
+		w := httptest.NewRecorder()
+		return testing.AllocsPerRun(100, func() {
+			h.ServeHTTP(w, request)
+		})
+	}
+
+	// reflect.Call accounts for the difference
+	cuttleAllocs, echoAllocs := allocs(r), allocs(e)
+	if cuttleAllocs > echoAllocs+4 {
+		t.Errorf("binding allocates %v times per request, plain echo allocates %v", cuttleAllocs, echoAllocs)
+	}
+}
@@ -0,0 +1,40 @@
+package cuttle
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fragment is the parsed prefix tag of a struct field, it lets a struct be reused with predictable names.
+// The prefix is added to the names its fields are read by, inline structs report their fields like embedded ones.
+//
+//	Owner   UserRef `prefix:"owner_"`        // owner_id, failures for Owner.ID
+//	Buyer   UserRef `prefix:"buyer_,inline"` // buyer_id, failures for buyer_ID
+//	PetRef  `prefix:"pet_"`                   // pet_id, failures for pet_ID
+type fragment struct {
+	prefix string
+	inline bool
+}
+
+func parsePrefixTag(structTag reflect.StructTag) (fragment, error) {
+	tag, ok := structTag.Lookup("prefix")
+	if !ok {
+		return fragment{}, nil
+	}
+	options := strings.Split(tag, ",")
+	f := fragment{prefix: options[0]}
+	for _, option := range options[1:] {
+		if option != "inline" {
+			return f, fmt.Errorf("unknown prefix option '%v'", option)
+		}
+		f.inline = true
+	}
+	return f, nil
+}
+
+// WithPrefix returns the source reading the prefixed name, generated binders use it for fields of prefixed structs
+func (s BindSource) WithPrefix(prefix string) BindSource {
+	s.key = newLookupKey(prefix + s.key.name)
+	return s
+}
@@ -0,0 +1,83 @@
+package cuttle
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type AddressQuery struct {
+	City    string `as:"city"`
+	Country string `as:"country"`
+}
+
+type OwnerRef struct {
+	ID uint `as:"id"`
+}
+
+type PetRef struct {
+	ID uint `as:"id"`
+}
+
+func TestPrefix(t *testing.T) {
+	type params struct {
+		OwnerRef `prefix:"owner_"`
+		PetRef   `prefix:"pet_"`
+		Home     AddressQuery `prefix:"home_"`
+		Work     AddressQuery `prefix:"work_,inline"`
+		Search   struct {
+			Page Page              `page:"max=50"`
+			Sort Sort              `sort:"name"`
+			Tags map[string]string `as:"tags"`
+			Near AddressQuery      `prefix:"near_"`
+		} `prefix:"s_"`
+	}
+	r := New()
+	var got params
+	r.GET("/owners/:owner_id", func(p params) error {
+		got = p
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/owners/1?pet_id=2&home_city=Oslo&home_country=NO"+
+		"&work_city=Bergen&work_country=NO&s_page=2&s_sort=-name&s_tags[a]=b&s_near_city=Moss&s_near_country=NO", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, uint(1), got.OwnerRef.ID)
+	assert.Equal(t, uint(2), got.PetRef.ID)
+	assert.Equal(t, AddressQuery{"Oslo", "NO"}, got.Home)
+	assert.Equal(t, AddressQuery{"Bergen", "NO"}, got.Work)
+	assert.Equal(t, 2, got.Search.Page.Number)
+	assert.Equal(t, Sort{{"name", true}}, got.Search.Sort)
+	assert.Equal(t, map[string]string{"a": "b"}, got.Search.Tags)
+	assert.Equal(t, AddressQuery{"Moss", "NO"}, got.Search.Near)
+
+	// embedded and inline structs report their fields with the prefix, the others with the field name
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/owners/1?pet_id=x&home_city=Oslo&work_city=Bergen&s_page=0", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `{"field":"pet_ID","error":"not a number: strconv.ParseUint: parsing \"x\": invalid syntax"}`)
+	assert.Contains(t, body, `{"field":"Search.Page","error":"page should be a positive number"}`)
+}
+
+func TestPrefix_PathParams(t *testing.T) {
+	r := New()
+	r.CollectErrors = true
+	r.GET("/pets/:id", func(p struct {
+		Pet struct {
+			ID uint `bind:"param"`
+		} `prefix:"pet_"`
+	}) error {
+		return nil
+	})
+	assert.Contains(t, r.Check().Error(), "path parameter 'pet_ID' is not in route '/pets/:id'")
+
+	r.GET("/owners", func(p struct {
+		Owner OwnerRef `prefix:"owner_,flat"`
+	}) error {
+		return nil
+	})
+	assert.Contains(t, r.Check().Error(), "unknown prefix option 'flat'")
+}
@@ -0,0 +1,100 @@
+package cuttle
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// provider resolves a value injected as a handler argument
+type provider func(ctx Context) (reflect.Value, error)
+
+// Provide registers a provider for the type it returns, handlers can then accept that type as an argument.
+// Providers have to be registered before the routes that use them.
+//
+//	func(ctx cuttle.Context) (*DB, error)  gets called on every request
+//	func() (*DB, error)                    gets called once, on the first request that needs it, until it succeeds
+func (r *Cuttle) Provide(fn interface{}) {
+	fnType := reflect.TypeOf(fn)
+	if fnType == nil || fnType.Kind() != reflect.Func {
+		panic(fmt.Sprintf("provider should be a func, got '%v'", fnType))
+	}
+	if fnType.NumOut() != 2 || fnType.Out(1) != errorType {
+		panic(fmt.Sprintf("provider '%v' should return (T, error)", fnType))
+	}
+	fnVal := reflect.ValueOf(fn)
+	provided := fnType.Out(0)
+
+	switch {
+	case fnType.NumIn() == 0:
+		// only a value is cached, a failing provider is called again by the next request. The lock is only taken
+		// until the provider succeeded, cached values are loaded without it
+		var mu sync.Mutex
+		var cached atomic.Value
+		r.addProvider(provided, func(ctx Context) (reflect.Value, error) {
+			if val, ok := cached.Load().(reflect.Value); ok {
+				return val, nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if val, ok := cached.Load().(reflect.Value); ok {
+				return val, nil
+			}
+			out := fnVal.Call(nil)
+			if !out[1].IsNil() {
+				return reflect.Value{}, out[1].Interface().(error)
+			}
+			cached.Store(out[0])
+			return out[0], nil
+		})
+	case fnType.NumIn() == 1 && fnType.In(0) == cutleContextType:
+		r.addProvider(provided, func(ctx Context) (reflect.Value, error) {
+			out := fnVal.Call([]reflect.Value{reflect.ValueOf(&ctx).Elem()})
+			if !out[1].IsNil() {
+				return reflect.Value{}, out[1].Interface().(error)
+			}
+			return out[0], nil
+		})
+	default:
+		panic(fmt.Sprintf("provider '%v' can only accept cuttle.Context or nothing", fnType))
+	}
+}
+
+// ProvideValue registers a singleton, handlers accepting the value's type get the same value on every request
+func (r *Cuttle) ProvideValue(value interface{}) {
+	if value == nil {
+		panic("cannot provide a nil value")
+	}
+	val := reflect.ValueOf(value)
+	r.addProvider(val.Type(), func(ctx Context) (reflect.Value, error) {
+		return val, nil
+	})
+}
+
+func (r *Cuttle) addProvider(t reflect.Type, p provider) {
+	if r.providers == nil {
+		r.providers = map[reflect.Type]provider{}
+	}
+	if _, ok := r.providers[t]; ok {
+		panic(fmt.Sprintf("a provider for '%v' is already registered", t))
+	}
+	r.providers[t] = p
+}
+
+// providerResolver returns the input resolver for an injected argument, or nil if there's no provider for the type
+func (r *Cuttle) providerResolver(t reflect.Type) func(ctx Context) (reflect.Value, bool, error) {
+	p, ok := r.providers[t]
+	if !ok {
+		return nil
+	}
+	return func(ctx Context) (reflect.Value, bool, error) {
+		val, err := p(ctx)
+		if err != nil {
+			return reflect.Value{}, true, fmt.Errorf("provider for '%v' failed: %w", t, err)
+		}
+		return val, true, nil
+	}
+}
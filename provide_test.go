@@ -0,0 +1,135 @@
+package cuttle
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type testDB struct {
+	Calls int
+}
+
+type testLogger struct {
+	Prefix string
+}
+
+func TestCuttle_Provide(t *testing.T) {
+	r := New()
+	singleton := &testDB{}
+	r.Provide(func() (*testDB, error) {
+		singleton.Calls++
+		return singleton, nil
+	})
+	r.ProvideValue(testLogger{Prefix: "cuttle"})
+	r.Provide(func(ctx Context) (string, error) {
+		token := ctx.Request().Header.Get("X-Token")
+		if token == "" {
+			return "", fmt.Errorf("no token")
+		}
+		return token, nil
+	})
+
+	r.GET("/test", func(params struct {
+		Query string
+	}, db *testDB, logger testLogger, token string, ctx Context) error {
+		assert.Equal(t, "cuttle", logger.Prefix)
+		assert.Equal(t, "secret", token)
+		return ctx.JSON(200, db.Calls)
+	})
+
+	for i := 0; i < 2; i++ {
+		request, err := http.NewRequest("GET", "http://localhost/test?query=hi", nil)
+		assert.NoError(t, err)
+		request.Header.Set("X-Token", "secret")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, request)
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "1\n", w.Body.String(), "singleton provider should only be called once")
+	}
+
+	request, err := http.NewRequest("GET", "http://localhost/test", nil)
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestCuttle_ProvideMissing(t *testing.T) {
+	type conn struct {
+		pool int
+	}
+	r := New()
+	assert.Panics(t, func() {
+		r.GET("/test", func(db chan *testDB, ctx Context) error {
+			return nil
+		})
+	})
+	assert.PanicsWithError(t, "invalid userHandler for GET /conn:\n\targ0 (cuttle.conn): binds no fields, register a provider with Provide or ProvideValue", func() {
+		r.GET("/conn", func(db conn, ctx Context) error {
+			return nil
+		})
+	})
+	assert.PanicsWithError(t, "invalid userHandler for GET /pool:\n\targ0 (*cuttle.conn): binds no fields, register a provider with Provide or ProvideValue", func() {
+		r.GET("/pool", func(db *conn, ctx Context) error {
+			return nil
+		})
+	})
+}
+
+func TestCuttle_ProvideRetry(t *testing.T) {
+	r := New()
+	calls := 0
+	r.Provide(func() (*testDB, error) {
+		calls++
+		if calls == 1 {
+			return nil, fmt.Errorf("not connected")
+		}
+		return &testDB{Calls: calls}, nil
+	})
+	r.GET("/test", func(db *testDB, ctx Context) error {
+		return ctx.JSON(200, db.Calls)
+	})
+
+	codes := []int{http.StatusInternalServerError, 200, 200}
+	for _, code := range codes {
+		request, err := http.NewRequest("GET", "http://localhost/test", nil)
+		assert.NoError(t, err)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, request)
+		assert.Equal(t, code, w.Code)
+		if code == 200 {
+			assert.Equal(t, "2\n", w.Body.String(), "the provider should be retried until it succeeds, then reused")
+		}
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestCuttle_ProvideConcurrent(t *testing.T) {
+	r := New()
+	var calls int32
+	r.Provide(func() (*testDB, error) {
+		atomic.AddInt32(&calls, 1)
+		return &testDB{}, nil
+	})
+	r.GET("/test", func(db *testDB, ctx Context) error {
+		return ctx.NoContent(http.StatusNoContent)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+			assert.Equal(t, http.StatusNoContent, w.Code)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package cuttle
+
+// the race detector allocates on its own, allocation counts don't hold under it
+const raceEnabled = true
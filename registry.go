@@ -0,0 +1,26 @@
+package cuttle
+
+// RouteInfo describes a route registered with cuttle, used to generate docs and specs from the router
+type RouteInfo struct {
+	Method string `json:"method"`
+	// Path as registered, with the param constraints > /users/:id<int>
+	Path string `json:"path"`
+	// Scopes required by the route's Scopes markers
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// RouteInfo returns the routes registered with cuttle in the order they were registered, a route registered
+// again replaces the previous one
+func (r *Cuttle) RouteInfo() []RouteInfo {
+	return append([]RouteInfo(nil), r.registry...)
+}
+
+func (r *Cuttle) register(info RouteInfo) {
+	for i, existing := range r.registry {
+		if existing.Method == info.Method && existing.Path == info.Path {
+			r.registry[i] = info
+			return
+		}
+	}
+	r.registry = append(r.registry, info)
+}
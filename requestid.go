@@ -0,0 +1,102 @@
+package cuttle
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"reflect"
+)
+
+// HeaderRequestID carries the id of a request, it's read from the request and set on the response
+const HeaderRequestID = echo.HeaderXRequestID
+
+// context key of the id of a request
+const requestIDKey = "cuttle.requestid"
+
+// RequestID is the id of the request, read from the X-Request-ID header or generated when it's missing.
+// Fields of this type are bound without a bind tag, `bind:"requestid"` binds it to a string.
+//
+//	ID cuttle.RequestID
+//	Trace string `bind:"requestid"`
+type RequestID string
+
+var requestIDType = reflect.TypeOf(RequestID(""))
+
+// GetRequestID returns the id of the request and sets it on the response.
+// Ids longer than 128 characters or with anything but printable ascii are replaced with a generated one.
+func GetRequestID(ctx echo.Context) RequestID {
+	if id, ok := ctx.Get(requestIDKey).(RequestID); ok {
+		return id
+	}
+	id := RequestID(ctx.Request().Header.Get(HeaderRequestID))
+	if !validRequestID(id) {
+		id = newRequestID()
+	}
+	ctx.Set(requestIDKey, id)
+	ctx.Response().Header().Set(HeaderRequestID, string(id))
+	return id
+}
+
+func validRequestID(id RequestID) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() RequestID {
+	var b [16]byte
+	rand.Read(b[:])
+	return RequestID(hex.EncodeToString(b[:]))
+}
+
+func requestIDResolver(_ string, ctx Context) string {
+	return string(GetRequestID(ctx))
+}
+
+func bindRequestID(ctx Context, field reflect.Value) error {
+	field.SetString(string(GetRequestID(ctx)))
+	return nil
+}
+
+// httpErrorHandler is echo's DefaultHTTPErrorHandler with the id of the request in the body and header,
+// routes echo doesn't know get one too
+func (r *Cuttle) httpErrorHandler(err error, ctx echo.Context) {
+	if !r.RequestIDs {
+		r.Echo.DefaultHTTPErrorHandler(err, ctx)
+		return
+	}
+	if ctx.Response().Committed {
+		return
+	}
+	he, ok := err.(*echo.HTTPError)
+	if !ok {
+		he = echo.NewHTTPError(http.StatusInternalServerError)
+	} else if internal, ok := he.Internal.(*echo.HTTPError); ok {
+		he = internal
+	}
+
+	id := GetRequestID(ctx)
+	message := he.Message
+	if m, ok := he.Message.(string); ok {
+		body := echo.Map{"message": m, "request_id": id}
+		if r.Debug {
+			body["error"] = err.Error()
+		}
+		message = body
+	}
+	if ctx.Request().Method == http.MethodHead {
+		err = ctx.NoContent(he.Code)
+	} else {
+		err = ctx.JSON(he.Code, message)
+	}
+	if err != nil {
+		r.Logger.Error(err)
+	}
+}
@@ -0,0 +1,133 @@
+package cuttle
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	logger := &recordingLogger{}
+	r := New()
+	r.RequestLogger = logger
+
+	var got struct {
+		ID    RequestID
+		Trace string
+	}
+	r.GET("/users", func(p struct {
+		ID    RequestID
+		Trace string `bind:"requestid"`
+		Limit int    `as:"limit,required"`
+	}) error {
+		got.ID, got.Trace = p.ID, p.Trace
+		return nil
+	})
+
+	// the id of the request is kept
+	request := httptest.NewRequest(http.MethodGet, "/users?limit=1", nil)
+	request.Header.Set(HeaderRequestID, "abc-123")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, request)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, RequestID("abc-123"), got.ID)
+	assert.Equal(t, "abc-123", got.Trace)
+	assert.Equal(t, "abc-123", rec.Header().Get(HeaderRequestID))
+	assert.Equal(t, RequestID("abc-123"), logger.entries[0].attrs["request_id"])
+
+	// invalid ids are replaced
+	for _, id := range []string{"", "has space", strings.Repeat("a", 129)} {
+		request = httptest.NewRequest(http.MethodGet, "/users?limit=1", nil)
+		request.Header.Set(HeaderRequestID, id)
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, request)
+		assert.Len(t, string(got.ID), 32, id)
+		assert.Equal(t, string(got.ID), got.Trace)
+		assert.Equal(t, string(got.ID), rec.Header().Get(HeaderRequestID))
+	}
+
+	// validation failures report it
+	request = httptest.NewRequest(http.MethodGet, "/users", nil)
+	request.Header.Set(HeaderRequestID, "failed-1")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, request)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var body struct {
+		RequestID string `json:"request_id"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "failed-1", body.RequestID)
+	assert.Equal(t, "failed-1", rec.Header().Get(HeaderRequestID))
+}
+
+func TestRequestIDs(t *testing.T) {
+	r := New()
+	r.GET("/ping", func(p struct {
+		Ctx Context
+	}) error {
+		return p.Ctx.NoContent(http.StatusNoContent)
+	})
+	r.GET("/fail", func(p struct{}) error {
+		return echo.NewHTTPError(http.StatusForbidden)
+	})
+
+	// every response gets an id
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	assert.Len(t, rec.Header().Get(HeaderRequestID), 32)
+
+	request := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	request.Header.Set(HeaderRequestID, "xyz")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, request)
+	assert.Equal(t, "xyz", rec.Header().Get(HeaderRequestID))
+
+	// error bodies include it, for routes echo doesn't know too
+	for path, body := range map[string]string{
+		"/fail":    `{"message":"Forbidden","request_id":"err-1"}`,
+		"/missing": `{"message":"Not Found","request_id":"err-1"}`,
+	} {
+		request = httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set(HeaderRequestID, "err-1")
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, request)
+		assert.Equal(t, body+"\n", rec.Body.String(), path)
+		assert.Equal(t, "err-1", rec.Header().Get(HeaderRequestID), path)
+	}
+
+	// ids are only set when something asks for them
+	r.RequestIDs = false
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	assert.Empty(t, rec.Header().Get(HeaderRequestID))
+	assert.Equal(t, `{"message":"Forbidden"}`+"\n", rec.Body.String())
+}
+
+func TestRequestIDs_ValidationFailures(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		r := New()
+		r.RequestIDs = enabled
+		r.GET("/search", func(p struct {
+			Query string `as:"q,required"`
+		}) error {
+			return nil
+		})
+
+		request := httptest.NewRequest(http.MethodGet, "/search", nil)
+		request.Header.Set(HeaderRequestID, "search")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, request)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		if enabled {
+			assert.Equal(t, "search", rec.Header().Get(HeaderRequestID))
+			assert.Contains(t, rec.Body.String(), `"request_id":"search"`)
+		} else {
+			assert.Empty(t, rec.Header().Get(HeaderRequestID))
+			assert.NotContains(t, rec.Body.String(), "request_id")
+		}
+	}
+}
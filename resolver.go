@@ -3,7 +3,6 @@ package cuttle
 import (
 	"fmt"
 	"reflect"
-	"strings"
 )
 
 type FromJson struct{}
@@ -17,30 +16,45 @@ func GetNameTag(a interface{}, index int) (string, reflect.StructTag) {
 type ContextResolvers []ContextResolverFunc
 type ContextResolverFunc func(string, Context) string
 
+// Deprecated: the file source now resolves the uploaded file's name, file fields are bound directly.
 const ResolveAsFile = "cuttle.resolveasfile"
 
 type CSRGetOption struct {
+	// Sensitive disables the lowercase name fallback, it doesn't hide the value, see Secret
 	Sensitive bool
 	Required  bool
+	// Secret redacts the value from debug logs and validation failures
+	Secret bool
+
+	// MaxBytes limits the size of the request body for body fields
+	MaxBytes int64
+
+	// file options, checked before the handler is called
+	MaxSize  int64
+	MaxCount int
+	Accept   []string
 }
 
 var ErrNoValueOnRequiredField = fmt.Errorf("no value found on required field")
 
 func (cr ContextResolvers) Get(name string, option CSRGetOption, ctx Context) (string, error) {
-	var err error
-	if option.Required {
-		err = ErrNoValueOnRequiredField
-	}
+	return cr.lookup(newLookupKey(name), option, ctx)
+}
+
+func (cr ContextResolvers) lookup(key lookupKey, option CSRGetOption, ctx Context) (string, error) {
 	for _, resolverFunc := range cr {
-		s := resolverFunc(name, ctx)
-		if s == "" && !option.Sensitive {
-			s = resolverFunc(strings.ToLower(name), ctx)
+		s := resolverFunc(key.name, ctx)
+		if s == "" && !option.Sensitive && key.lower != key.name {
+			s = resolverFunc(key.lower, ctx)
 		}
 		if s != "" {
 			return s, nil
 		}
 	}
-	return "", err
+	if option.Required {
+		return "", ErrNoValueOnRequiredField
+	}
+	return "", nil
 }
 
 var ctxResolvers = map[string]ContextResolverFunc{
@@ -61,8 +75,14 @@ var ctxResolvers = map[string]ContextResolverFunc{
 		return x
 	},
 	"file": func(name string, ctx Context) string {
-		return ResolveAsFile
+		fh, err := ctx.FormFile(name)
+		if err != nil {
+			return ""
+		}
+		return fh.Filename
 	},
+	"claims":    claimsResolver,
+	"requestid": requestIDResolver,
 }
 
 func GetResolvers(solvers ...string) ContextResolvers {
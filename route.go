@@ -0,0 +1,64 @@
+package cuttle
+
+import (
+	"github.com/labstack/echo/v4"
+	"regexp"
+)
+
+// routeGroup holds the handlers registered on the same echo route, echo can't tell /users/:id<int> and
+// /users/:name apart so the group tries their constraints in the order they were registered
+type routeGroup struct {
+	routes []*route
+}
+
+type route struct {
+	params  *pathParams
+	handler echo.HandlerFunc
+}
+
+// shapeOf returns the route as echo sees it, param names and constraints don't matter > GET /users/:
+func shapeOf(method string, params *pathParams) string {
+	return method + " " + paramName.ReplaceAllString(params.path, ":")
+}
+
+var paramName = regexp.MustCompile(`:[^/]*`)
+
+// addRoute registers the handler with echo, or adds it to the group already registered for the same shape.
+// Registering the same route again replaces its handler.
+func (r *Cuttle) addRoute(method string, params *pathParams, handler echo.HandlerFunc, middleware ...MiddlewareFunc) {
+	// route middleware is applied here since the group is a single echo route
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	rt := &route{params: params, handler: handler}
+
+	shape := shapeOf(method, params)
+	group, ok := r.routes[shape]
+	if !ok {
+		if r.routes == nil {
+			r.routes = map[string]*routeGroup{}
+		}
+		group = &routeGroup{}
+		r.routes[shape] = group
+		r.Echo.Add(method, params.path, group.handle)
+	}
+	for i, existing := range group.routes {
+		if existing.params.route == params.route {
+			group.routes[i] = rt
+			return
+		}
+	}
+	group.routes = append(group.routes, rt)
+}
+
+// handle runs the first route whose constraints match, requests matching none of them are not found
+func (g *routeGroup) handle(ctx echo.Context) error {
+	values := ctx.ParamValues()
+	for _, rt := range g.routes {
+		if rt.params.match(values) {
+			ctx.SetParamNames(rt.params.names...)
+			return rt.handler(ctx)
+		}
+	}
+	return echo.ErrNotFound
+}
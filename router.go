@@ -1,17 +1,16 @@
 package cuttle
 
 import (
-	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/gommon/log"
-	"io"
-	"mime/multipart"
 	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var cutleContextType = reflect.TypeOf((*Context)(nil)).Elem()
@@ -28,44 +27,142 @@ type Context interface {
 type Cuttle struct {
 	*echo.Echo
 	ErrorHandler ErrorHandlerFunc
+	// MaxBodyBytes is the default body size limit for body fields, 0 means no limit
+	MaxBodyBytes int64
+
+	// CollectErrors records invalid handlers instead of panicking, they're returned by Check
+	CollectErrors bool
+
+	// RequestIDs sets X-Request-ID on every response and adds it to error bodies, on by default.
+	// Validation failures read it when the routes are registered.
+	RequestIDs bool
+
+	// RequestLogger gets a structured log of every request when set, echo's Logger is left alone
+	RequestLogger Logger
+	// Tracer gets a span around the binding and the handler call of every request when set
+	Tracer Tracer
+	// Metrics records per route metrics when set, see EnableMetrics
+	Metrics *Metrics
+
+	// SSEHeartbeat is the interval of the comments keeping event streams open, 15s when 0 and none when negative
+	SSEHeartbeat time.Duration
+
+	// Authenticator authenticates requests to handlers with Principal or `bind:"claims"` fields
+	Authenticator Authenticator
+
+	providers map[reflect.Type]provider
+	// encoders of Respond, the defaults until RegisterEncoder is called
+	encoders         []Encoder
+	registrationErrs RegistrationErrors
+	registry         []RouteInfo
+	// routes by their shape in echo > GET /users/:
+	routes map[string]*routeGroup
 }
 
 func New() *Cuttle {
 	e := echo.New()
 	e.HideBanner = true
 	e.Logger.Info("[Cuttle 3:>] is based off Echo")
-	return &Cuttle{
-		e,
-		nil,
+	r := &Cuttle{
+		Echo:       e,
+		RequestIDs: true,
 	}
+	e.HTTPErrorHandler = r.httpErrorHandler
+	return r
 }
 
 func (r *Cuttle) Method(method, path string, userHandler interface{}, middleware ...MiddlewareFunc) {
-	finalResolver := r.handle(path, userHandler)
-
-	r.Echo.Add(method, path, func(context echo.Context) error {
-		in, err := finalResolver(Context(context))
-		if err != nil {
-			return fmt.Errorf("request validation failed: %w", err)
+	plan, err := r.handle(method, path, userHandler)
+	if err != nil {
+		if r.CollectErrors {
+			r.registrationErrs = append(r.registrationErrs, err)
+			return
 		}
+		panic(err)
+	}
 
-		// assume that the validation failed
-		if in == nil {
-			return nil
+	r.register(RouteInfo{Method: method, Path: path, Scopes: plan.scopes})
+	r.addRoute(method, plan.route, func(context echo.Context) error {
+		if r.RequestIDs {
+			GetRequestID(context)
 		}
-
-		retVal := reflect.ValueOf(userHandler).Call(in)
-		if retVal[0].IsNil() {
-			return nil
+		if r.RequestLogger == nil && r.Metrics == nil && r.Tracer == nil {
+			return r.serve(plan, context, nil)
+		}
+		entry := &requestLog{method: method, route: path, start: time.Now()}
+		if r.Metrics != nil {
+			entry.countBody(context.Request())
 		}
-		if r.ErrorHandler != nil {
-			r.ErrorHandler(retVal[0].Interface().(error), context)
-			return nil
+		err := r.serve(plan, context, entry)
+		status := responseStatus(context, err)
+		if r.RequestLogger != nil {
+			r.logRequest(entry, context, status, err)
 		}
-		return retVal[0].Interface().(error)
+		if r.Metrics != nil {
+			failures, _ := context.Get(failuresKey).([]ValidationFail)
+			r.Metrics.record(entry, status, failures)
+		}
+		return err
 	}, middleware...)
 }
 
+// serve binds the params and calls the handler, the bound params and timings are added to entry when it's set
+func (r *Cuttle) serve(plan *routePlan, context echo.Context, entry *requestLog) error {
+	var span Span
+	parent := context.Request().Context()
+	if r.Tracer != nil {
+		extractTraceParent(context)
+		parent = context.Request().Context()
+		span = r.startSpan(context, parent, "cuttle.bind", entry)
+	}
+	in, err := plan.resolve(Context(context))
+	if entry != nil {
+		entry.bound = time.Now()
+	}
+	if span != nil {
+		endBindSpan(span, plan, Context(context), err)
+		context.SetRequest(context.Request().WithContext(parent))
+	}
+	if err != nil {
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			return httpErr
+		}
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+
+	// assume that the validation failed
+	if in == nil {
+		return nil
+	}
+	if entry != nil && r.RequestLogger != nil {
+		entry.params = plan.logParams(in)
+	}
+
+	if r.Tracer != nil {
+		span = r.startSpan(context, parent, "cuttle.handler", entry)
+	}
+	err = plan.run(Context(context), in)
+	if entry != nil {
+		entry.handled = time.Now()
+	}
+	if span != nil {
+		endSpan(span, err)
+	}
+	if err == nil {
+		return nil
+	}
+	// body readers handed to the handler hit the limit while it's reading
+	if isBodyTooLarge(err) {
+		err = echo.ErrStatusRequestEntityTooLarge
+	}
+	if r.ErrorHandler != nil {
+		r.ErrorHandler(err, context)
+		return nil
+	}
+	return err
+}
+
 func (r *Cuttle) GET(path string, userHandler interface{}, middleware ...MiddlewareFunc) {
 	r.Method("GET", path, userHandler, middleware...)
 }
@@ -107,274 +204,200 @@ type ValidationFail struct {
 	Err   string `json:"error"`
 }
 
-// handle returns a finalresolver function that returns the arguments passed to the userHandler as an array of reflect.Value
-func (r *Cuttle) handle(path string, userHandler interface{}) FinalResolver {
+// handle compiles the routePlan that resolves the arguments passed to the userHandler,
+// every unsupported argument and field is reported in the returned error
+func (r *Cuttle) handle(method, path string, userHandler interface{}) (*routePlan, *HandlerError) {
+	check := &HandlerError{Method: method, Path: path}
+
 	// validate userHandler
 	handlerType := reflect.TypeOf(userHandler)
-	if handlerType.Kind() != reflect.Func {
-		panic(fmt.Sprintf("type of userHandler is '%v', not func", handlerType.Kind()))
+	if handlerType == nil || handlerType.Kind() != reflect.Func {
+		check.add("userHandler", handlerType, "not a func")
+		return nil, check
 	}
 	if handlerType.NumIn() < 1 {
-		panic(fmt.Sprintf("userHandler '%v' can only accept one or more argument", path))
+		check.add("userHandler", handlerType, "can only accept one or more argument")
+	}
+	// handlers sending server-sent events return their events > (<-chan cuttle.Event, error)
+	events := handlerType.NumOut() == 2 && handlerType.Out(0) == eventChanType && handlerType.Out(1) == errorType
+	if !events && (handlerType.NumOut() != 1 || handlerType.Out(0) != errorType) {
+		check.add("userHandler", handlerType, "should only return error or (<-chan cuttle.Event, error)")
 	}
 
-	if handlerType.NumOut() != 1 || handlerType.Out(0).String() != "error" {
-		panic(fmt.Sprintf("userHandler '%v' should only return error", path))
+	params, err := parsePathParams(path)
+	if err != nil {
+		check.add("path", nil, err.Error())
+		return nil, check
+	}
+	plan := &routePlan{handler: reflect.ValueOf(userHandler), route: params}
+	if events {
+		plan.events = r.streamEvents
 	}
-	// value to pass to function argument
-	//   |			validation passed
-	//   |           |     unhandled error
-	var inputResolvers []func(ctx Context) (reflect.Value, bool, error)
 	for i := 0; i < handlerType.NumIn(); i++ {
-		var res func(ctx Context) (reflect.Value, bool, error)
 		inType := handlerType.In(i)
+		argPath := fmt.Sprintf("arg%v", i)
 		log.Debug("In Type String ", inType.String())
 
-		if inType.Kind() == reflect.Struct && inType.NumField() > 0 {
-			firstField := inType.Field(0)
+		// injected services, registered with Provide or ProvideValue
+		if res := r.providerResolver(inType); res != nil {
+			plan.args = append(plan.args, argPlan{resolve: res})
+			continue
+		}
+
+		// handlers writing their own server-sent events
+		if inType == sseWriterType {
+			plan.args = append(plan.args, r.sseWriterResolver())
+			continue
+		}
+
+		// pointer params are bound in place, letting the handler mutate them without copying the struct
+		structType, isPtr := inType, false
+		if inType.Kind() == reflect.Ptr && inType.Elem().Kind() == reflect.Struct {
+			structType, isPtr = inType.Elem(), true
+		}
+		bound := func(ptr reflect.Value) reflect.Value {
+			if isPtr {
+				return ptr
+			}
+			return ptr.Elem()
+		}
+
+		var res func(ctx Context) (reflect.Value, bool, error)
+		switch {
+		case structType.Kind() == reflect.Struct && structType.NumField() > 0:
+			firstField := structType.Field(0)
 			switch firstField.Type {
 			case reflect.TypeOf(FromJson{}):
-				log.Debug("[JSON] Assigned as json", inType)
+				log.Debug("[JSON] Assigned as json", structType)
+				option, _, err := getTags(firstField.Tag, firstField.Name) // checks for > FromJson `as:",maxbytes=1MB"`
+				if err != nil {
+					check.add(argPath+"."+firstField.Name, firstField.Type, err.Error())
+				}
 				res = func(ctx Context) (reflect.Value, bool, error) {
-					val := reflect.New(inType)
-					return val.Elem(), true, json.NewDecoder(ctx.Request().Body).Decode(val.Interface())
+					val := reflect.New(structType)
+					limitBody(ctx, r.bodyLimit(option))
+					err := json.NewDecoder(ctx.Request().Body).Decode(val.Interface())
+					if isBodyTooLarge(err) {
+						err = echo.ErrStatusRequestEntityTooLarge
+					}
+					return bound(val), true, err
 				}
 			case reflect.TypeOf(AsReturn{}):
-				log.Debug("[Return] struct assigned as return type", inType)
+				log.Debug("[Return] struct assigned as return type", structType)
 				statusCode := firstField.Tag.Get("code")
 				_ = statusCode // TODO: handle return values
 				res = func(ctx Context) (reflect.Value, bool, error) {
-					val := reflect.New(inType)
-					return val.Elem(), true, nil
+					val := reflect.New(structType)
+					return bound(val), true, nil
 				}
 			default:
-				var resolvers = r.structResolvers(inType)
-
-				// This gets called during the request
-				res = func(context Context) (reflect.Value, bool, error) {
-					in := reflect.New(inType)
-					var failures []ValidationFail
-
-					for i, resolver := range resolvers {
-						if resolver == nil {
-							continue
-						}
-						value, err := resolver(context)
-						if err != nil {
-							failures = append(failures, ValidationFail{
-								Field: inType.Field(i).Name,
-								Err:   err.Error(),
-							})
-							log.Debug("Validation failed", failures[len(failures)-1])
-							continue
-						}
-						log.Debug("[DEBUG] Setting struct value", i, value)
-						in.Elem().Field(i).Set(reflect.ValueOf(value))
-					}
-
-					if len(failures) != 0 {
-						log.Debug("Validation failed for this request")
-						return reflect.Value{}, false, context.JSON(http.StatusBadRequest, map[string]interface{}{
-							"message": "validation failed",
-							"fields":  failures,
-						})
-					}
-
-					return in.Elem(), true, nil
+				// generated binders are preferred over reflection
+				if reflect.PtrTo(structType).Implements(binderType) {
+					log.Debug("[Binder] using generated binder", structType)
+					binder := &structPlan{t: structType, zero: reflect.Zero(structType), binder: true, requestIDs: r.RequestIDs}
+					// the fields still tell which path params are read and their constraints, the binder decides
+					// which fields are supported
+					fields := r.compileStruct(structType, argPath, "", params, &HandlerError{})
+					plan.scopes = append(plan.scopes, fields.scopes...)
+					params.any = true
+					arg := binder.argument(isPtr)
+					arg.params = fields
+					plan.args = append(plan.args, arg)
+					continue
+				}
+				reported := len(check.Fields)
+				structPlan := r.compileStruct(structType, argPath, "", params, check)
+				plan.scopes = append(plan.scopes, structPlan.scopes...)
+				// services without a provider would be passed zeroed, fields already reported aren't bound either
+				if len(check.Fields) == reported && structPlan.bindsNothing() {
+					check.add(argPath, inType, "binds no fields, register a provider with Provide or ProvideValue")
 				}
+				if structPlan.streaming && containsField(structType, isFileType) {
+					check.add(argPath, structType, "cannot have both cuttle.PartStream and file fields")
+				}
+				plan.args = append(plan.args, structPlan.argument(isPtr))
+				continue
+			}
+		// struct{} binds nothing
+		case structType.Kind() == reflect.Struct:
+			res = func(context Context) (reflect.Value, bool, error) {
+				return bound(reflect.New(structType)), true, nil
 			}
-
-			inputResolvers = append(inputResolvers, res)
-			continue
-		}
-
 		// pass the usual echo context if it's just that
-		if inType.Implements(cutleContextType) {
-			// This gets called during the request
+		case inType.Implements(cutleContextType):
+			params.any = true
 			res = func(context Context) (reflect.Value, bool, error) {
-				return reflect.ValueOf(context), true, nil
+				return contextValue(context, inType), true, nil
 			}
+		default:
+			check.add(argPath, inType, "unsupported argument type, register a provider with Provide or ProvideValue")
 		}
 
-		inputResolvers = append(inputResolvers, res)
+		plan.args = append(plan.args, argPlan{resolve: res})
 	}
 
-	var finalResolver FinalResolver = func(ctx Context) ([]reflect.Value, error) {
-		var vals []reflect.Value
-		for _, resolver := range inputResolvers {
-			value, ok, err := resolver(ctx)
-			if !ok {
-				return nil, fmt.Errorf("request validation failed")
-			}
-			if err != nil {
-				return nil, err
-			}
-			vals = append(vals, value)
-		}
-		return vals, nil
+	if len(plan.scopes) != 0 && r.Authenticator == nil {
+		check.add("userHandler", handlerType, "requires scopes %v but no Authenticator is set", plan.scopes)
 	}
+	plan.authorize = r.authorizer(plan.scopes)
 
-	return finalResolver
+	if len(check.Fields) != 0 {
+		return nil, check
+	}
+	// the params can still be read by middleware, so this is only a warning
+	for _, param := range params.unused() {
+		log.Warnf("%v %v: path parameter '%v' is not bound by any field", method, path, param)
+	}
+	return plan, nil
 }
 
-// structResolvers only gets called on initialization of the handler, not during the request
-func (r *Cuttle) structResolvers(inT reflect.Type) []ResolverFunc {
-	var resolvers []ResolverFunc
-	// iterate through struct fields
-	for i := 0; i < inT.NumField(); i++ {
-		var structResolver ResolverFunc = nil
-		field := inT.Field(i)
-		structTag := inT.Field(i).Tag
-
-		getOption, tag := r.getTags(structTag, inT.Field(i).Name)
-		log.Debug("[DEBUG] field info:", tag, structTag)
-
-		var ctxResolvers ContextResolvers
-		lookup, ok := structTag.Lookup("bind") // checks for > Field Type `bind:"query,param"`
-		if ok {                                //						    ^^^^^^^^^^^^^^^^^
-			ctxResolvers = GetResolvers(strings.Split(lookup, ",")...)
-		} else {
-			// default resolves if theres no specified bind
-			ctxResolvers = GetResolvers("param", "query")
-		}
-		log.Debug("[DEBUG] resolvers:", tag, ctxResolvers)
-
-		// skip unexported fields
-		if !field.IsExported() {
-			resolvers = append(resolvers, nil)
-			continue
-		}
-
-		// handles unexported fields, nil as resolver to skip. Checks for > Field Type `return:"200"`
-		// value will be the http status code
-		value, ok := structTag.Lookup("return")
-		if ok || !field.IsExported() {
-			log.Debug("[DEBUG] field is unexported or return", field, ok, field.IsExported())
-			resolvers = append(resolvers, nil)
-			if !ok {
-			}
-			_ = value
-			// TODO: handle return fields here
-			continue
-		}
-
-		// handles coercion from webRequest to struct type
-		switch field.Type.Kind() {
-		case reflect.String:
-			structResolver = func(ctx Context) (interface{}, error) {
-				return ctxResolvers.Get(tag, getOption, ctx)
-			}
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			structResolver = func(ctx Context) (interface{}, error) {
-				get, err := ctxResolvers.Get(tag, getOption, ctx)
-				if err != nil {
-					return nil, err
-				}
-				atoi, err := strconv.Atoi(get)
-				if err != nil {
-					return reflect.Value{}, fmt.Errorf("not a number: %w", err)
-				}
-				ret := reflect.New(field.Type)
-				ret.Elem().SetInt(int64(atoi))
-				return ret.Elem().Interface(), nil
-			}
-		case reflect.Float32, reflect.Float64:
-			structResolver = func(ctx Context) (interface{}, error) {
-				get, err := ctxResolvers.Get(tag, getOption, ctx)
-				if err != nil {
-					return nil, err
-				}
-				val, err := strconv.ParseFloat(get, 64)
-				if err != nil {
-					return reflect.Value{}, fmt.Errorf("not a number: %w", err)
-				}
-				ret := reflect.New(field.Type)
-				ret.Elem().SetFloat(val)
-				return ret.Elem().Interface(), nil
-			}
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			structResolver = func(ctx Context) (interface{}, error) {
-				get, err := ctxResolvers.Get(tag, getOption, ctx)
-				if err != nil {
-					return nil, err
-				}
-				val, err := strconv.ParseUint(get, 10, 64)
-				if err != nil {
-					return reflect.Value{}, fmt.Errorf("not a number: %w", err)
-				}
-				ret := reflect.New(field.Type)
-				ret.Elem().SetUint(val)
-				return ret.Elem().Interface(), nil
-			}
-		case reflect.Struct:
-			resolvers := r.structResolvers(field.Type)
-			structResolver = func(ctx Context) (interface{}, error) {
-				ret := reflect.New(field.Type)
-				var failures []ValidationFail
-
-				for i, resolver := range resolvers {
-					if resolver == nil {
-						continue
-					}
-					value, err := resolver(ctx)
-					if err != nil {
-						failures = append(failures, ValidationFail{
-							Field: field.Type.Field(i).Name,
-							Err:   err.Error(),
-						})
-						log.Debug("Validation failed", failures[len(failures)-1])
-						continue
-					}
-					log.Debug("[DEBUG] Setting struct value", i, value)
-					ret.Elem().Field(i).Set(reflect.ValueOf(value))
-				}
-
-				if len(failures) != 0 {
-					log.Debug("Validation failed for this request")
-					return nil, ctx.JSON(http.StatusBadRequest, map[string]interface{}{
-						"message": "validation failed",
-						"fields":  failures,
-					})
-				}
+// fieldTags are the parsed bind and as tags of a struct field
+type fieldTags struct {
+	// name looked up from the request
+	name      string
+	option    CSRGetOption
+	resolvers ContextResolvers
+	bindBody  bool
+	// bindParam is set when param is listed in the bind tag, defaultBind when there's no bind tag
+	bindParam   bool
+	defaultBind bool
+	bindClaims  bool
+}
 
-				return ret.Elem().Interface(), nil
-			}
-		case reflect.Interface:
-			log.Debug("[DEBUG] Field", field.Type, "as interface", field.Type)
-
-			// Check if its a file reader then pipe the field file writer
-			if field.Type == reflect.TypeOf((*io.Reader)(nil)).Elem() {
-				log.Debug("[DEBUG] assigning field as body:", field.Name)
-				structResolver = func(ctx Context) (interface{}, error) {
-					return bufio.NewReader(ctx.Request().Body), nil
-				}
-			}
-		// checks for pointer struct fields
-		case reflect.Ptr:
-			// if a multipart fileheader then return the form's fileheader
-			if field.Type.AssignableTo(reflect.TypeOf((*multipart.FileHeader)(nil))) {
-				log.Debug("[DEBUG] assigning field as file header:", field.Name)
-				structResolver = func(ctx Context) (interface{}, error) {
-					return ctx.FormFile(tag)
-				}
-			}
-		}
+func (t fieldTags) source() BindSource {
+	return BindSource{
+		resolvers: t.resolvers,
+		key:       newLookupKey(t.name),
+		option:    t.option,
+	}
+}
 
-		// Set as echo.context here
-		if field.Type.ConvertibleTo(cutleContextType) {
-			log.Debug("[DEBUG] Field", field.Type, "implements", cutleContextType)
-			structResolver = func(ctx Context) (interface{}, error) {
-				return ctx, nil
+func parseFieldTags(fieldName string, structTag reflect.StructTag) (fieldTags, error) {
+	var tags fieldTags
+	var err error
+	tags.option, tags.name, err = getTags(structTag, fieldName)
+
+	lookup, ok := structTag.Lookup("bind") // checks for > Field Type `bind:"query,param"`
+	if ok {                                //						    ^^^^^^^^^^^^^^^^^
+		sources := strings.Split(lookup, ",")
+		tags.resolvers = GetResolvers(sources...)
+		for _, source := range sources {
+			if _, ok := ctxResolvers[source]; !ok && source != "body" && err == nil {
+				err = fmt.Errorf("unknown bind source '%v'", source)
 			}
+			tags.bindBody = tags.bindBody || source == "body"
+			tags.bindParam = tags.bindParam || source == "param"
+			tags.bindClaims = tags.bindClaims || source == "claims"
 		}
-
-		resolvers = append(resolvers, structResolver)
+	} else {
+		// default resolves if theres no specified bind
+		tags.resolvers = GetResolvers("param", "query")
+		tags.defaultBind = true
 	}
-
-	return resolvers
+	return tags, err
 }
 
-func (r *Cuttle) getTags(structTag reflect.StructTag, tag string) (CSRGetOption, string) {
+func getTags(structTag reflect.StructTag, tag string) (CSRGetOption, string, error) {
 	getOption := CSRGetOption{
 		Sensitive: false,
 		Required:  false,
@@ -387,15 +410,49 @@ func (r *Cuttle) getTags(structTag reflect.StructTag, tag string) (CSRGetOption,
 			tag = i[0]
 		}
 		if len(i) > 1 {
+			var lastKey string
 			for _, v := range i[1:] {
-				switch v {
+				kv := strings.SplitN(v, "=", 2)
+				// accept takes a comma separated list > `as:"avatar,accept=image/png,image/jpeg"`
+				if len(kv) == 1 && lastKey == "accept" && strings.Contains(v, "/") {
+					getOption.Accept = append(getOption.Accept, v)
+					continue
+				}
+				lastKey = kv[0]
+				switch kv[0] {
 				case "sensitive":
 					getOption.Sensitive = true
+				case "secret":
+					getOption.Secret = true
 				case "required":
 					getOption.Required = true
+				case "maxsize":
+					size, err := parseSize(kv[len(kv)-1])
+					if err != nil {
+						return getOption, tag, fmt.Errorf("invalid maxsize: %w", err)
+					}
+					getOption.MaxSize = size
+				case "maxbytes":
+					size, err := parseSize(kv[len(kv)-1])
+					if err != nil {
+						return getOption, tag, fmt.Errorf("invalid maxbytes: %w", err)
+					}
+					getOption.MaxBytes = size
+				case "maxcount":
+					count, err := strconv.Atoi(kv[len(kv)-1])
+					if err != nil {
+						return getOption, tag, fmt.Errorf("invalid maxcount: %w", err)
+					}
+					getOption.MaxCount = count
+				case "accept":
+					if len(kv) == 2 && kv[1] != "" {
+						getOption.Accept = append(getOption.Accept, kv[1])
+					}
+				default:
+					return getOption, tag, fmt.Errorf("unknown as option '%v'", kv[0])
 				}
 			}
 		}
 	}
-	return getOption, tag
+	return getOption, tag, nil
 }
@@ -42,7 +42,7 @@ func TestRouter_GETOnlyEcho(t *testing.T) {
 func TestRouter_GET(t *testing.T) {
 	r := New()
 	r.GET("/test", func(a struct {
-		Query string `bind:"query,json" as:"q,required"`
+		Query string `bind:"query" as:"q,required"`
 		Count int
 		Ctx   Context
 		_     string `return:"200"`
@@ -69,7 +69,7 @@ func TestRouter_GETNested(t *testing.T) {
 	r := New()
 
 	type Nested struct {
-		Query string `bind:"query,json" as:"q,required"`
+		Query string `bind:"query" as:"q,required"`
 	}
 	r.GET("/test", func(a struct {
 		Nested
@@ -99,7 +99,7 @@ func TestRouter_GETNested(t *testing.T) {
 func TestRouter_GETValidationFailed(t *testing.T) {
 	r := New()
 	r.GET("/test", func(a struct {
-		Query string `bind:"query,json" json:"query,omitempty"`
+		Query string `bind:"query" json:"query,omitempty"`
 		Count int
 		Ctx   Context
 		_     string `return:"200"`
@@ -307,3 +307,62 @@ func TestHandler_FormFileDump(t *testing.T) {
 	fmt.Printf("[%v] %v\n", rec.Code, rec.Body.String())
 
 }
+
+func TestRouter_PointerParams(t *testing.T) {
+	type searchParams struct {
+		Query string `as:"q,required"`
+		Count int
+	}
+	type untaggedParams struct {
+		Query string
+		Count int
+	}
+	type person struct {
+		FromJson
+		FirstName string `json:"first_name"`
+	}
+
+	r := New()
+	r.GET("/search", func(params *searchParams, ctx Context) error {
+		params.Count *= 2
+		return ctx.JSON(200, params)
+	})
+	r.POST("/person", func(params *person, ctx Context) error {
+		return ctx.String(200, params.FirstName)
+	})
+	// untagged fields are read from the query like they are for struct values
+	r.GET("/untagged", func(params *untaggedParams, ctx Context) error {
+		return ctx.JSON(200, params)
+	})
+	r.GET("/empty", func(params *struct{}, ctx Context) error {
+		assert.NotNil(t, params)
+		return ctx.NoContent(200)
+	})
+
+	request, err := http.NewRequest("GET", "http://localhost/search?q=cuttle&count=2", nil)
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, request)
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"Query":"cuttle","Count":4}`, w.Body.String())
+
+	request, err = http.NewRequest("POST", "http://localhost/person", bytes.NewBufferString(`{"first_name":"joe"}`))
+	assert.NoError(t, err)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, request)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "joe", w.Body.String())
+
+	request, err = http.NewRequest("GET", "http://localhost/untagged?query=cuttle&count=3", nil)
+	assert.NoError(t, err)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, request)
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"Query":"cuttle","Count":3}`, w.Body.String())
+
+	request, err = http.NewRequest("GET", "http://localhost/empty", nil)
+	assert.NoError(t, err)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, request)
+	assert.Equal(t, 200, w.Code)
+}
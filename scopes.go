@@ -0,0 +1,79 @@
+package cuttle
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Scopes marks the scopes a route requires, the principal needs every listed scope or the request fails with a
+// 403 before the rest of the params are bound
+//
+//	_ cuttle.Scopes `scopes:"orders:read,orders:write"`
+type Scopes struct{}
+
+var scopesType = reflect.TypeOf(Scopes{})
+
+// HasScope checks if the principal was granted scope
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// parseScopes returns the scopes of a Scopes marker
+func parseScopes(tag reflect.StructTag) []string {
+	var scopes []string
+	for _, scope := range strings.Split(tag.Get("scopes"), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// authorizer returns the check run before the params are bound, nil when the route requires no scopes
+func (r *Cuttle) authorizer(scopes []string) func(ctx Context) error {
+	if len(scopes) == 0 {
+		return nil
+	}
+	return func(ctx Context) error {
+		principal, err := r.authenticate(ctx, false)
+		if err != nil {
+			return err
+		}
+		for _, scope := range scopes {
+			if !principal.HasScope(scope) {
+				return echo.NewHTTPError(http.StatusForbidden).SetInternal(fmt.Errorf("missing scope '%v'", scope))
+			}
+		}
+		return nil
+	}
+}
+
+// jwtScopes reads the granted scopes from the scope claim, a space separated string, or the scp and scopes
+// claims, arrays of strings
+func jwtScopes(claims map[string]interface{}) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	for _, name := range []string{"scp", "scopes"} {
+		values, ok := claims[name].([]interface{})
+		if !ok {
+			continue
+		}
+		scopes := make([]string, 0, len(values))
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+		return scopes
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package cuttle
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type orderScopes struct {
+	_ Scopes `scopes:"orders:read"`
+}
+
+func TestScopes(t *testing.T) {
+	r := New()
+	r.Authenticator = &JWTAuthenticator{Secret: testSecret}
+	r.POST("/orders", func(p struct {
+		_     Scopes `scopes:"orders:write"`
+		Read  orderScopes
+		Query string `as:"q,required"`
+	}) error {
+		return nil
+	})
+
+	for name, tc := range map[string]struct {
+		claims map[string]interface{}
+		code   int
+	}{
+		"granted":        {map[string]interface{}{"sub": "joe", "scope": "orders:read orders:write"}, 200},
+		"scp claim":      {map[string]interface{}{"sub": "joe", "scp": []string{"orders:read", "orders:write"}}, 200},
+		"missing nested": {map[string]interface{}{"sub": "joe", "scope": "orders:write"}, 403},
+		"missing":        {map[string]interface{}{"sub": "joe", "scope": "orders:read"}, 403},
+		"unauthorized":   {nil, 401},
+	} {
+		request := httptest.NewRequest(http.MethodPost, "/orders?q=1", nil)
+		if tc.claims != nil {
+			request.Header.Set("Authorization", "Bearer "+signToken(t, "HS256", tc.claims, testSecret))
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, request)
+		assert.Equal(t, tc.code, rec.Code, name)
+	}
+
+	// scopes are checked before the params are validated
+	request := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	request.Header.Set("Authorization", "Bearer "+signToken(t, "HS256", map[string]interface{}{"sub": "joe"}, testSecret))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, request)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	assert.Equal(t, []RouteInfo{{Method: "POST", Path: "/orders", Scopes: []string{"orders:write", "orders:read"}}}, r.RouteInfo())
+}
+
+func TestScopes_NoAuthenticator(t *testing.T) {
+	r := New()
+	assert.Panics(t, func() {
+		r.GET("/orders", func(p orderScopes) error {
+			return nil
+		})
+	})
+}
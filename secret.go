@@ -0,0 +1,82 @@
+package cuttle
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// redacted replaces secret values in logs, validation failures and json
+const redacted = "[REDACTED]"
+
+// Secret holds a value that never shows up in logs, validation failures or json, it's bound like T with the
+// secret option.
+//
+//	Token cuttle.Secret[string] `bind:"header" as:"X-Security-Token"`
+//	validateUser(params.Token.Value())
+type Secret[T any] struct {
+	value T
+}
+
+// NewSecret wraps value
+func NewSecret[T any](value T) Secret[T] {
+	return Secret[T]{value: value}
+}
+
+// Value returns the wrapped value
+func (s Secret[T]) Value() T {
+	return s.value
+}
+
+func (s Secret[T]) String() string {
+	return redacted
+}
+
+func (s Secret[T]) GoString() string {
+	return redacted
+}
+
+func (s Secret[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redacted)
+}
+
+func (s *Secret[T]) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &s.value)
+}
+
+// secretField is implemented by *Secret[T], lets the binder set the value of any instantiation
+type secretField interface {
+	secretType() reflect.Type
+	setSecret(v reflect.Value)
+}
+
+var secretFieldType = reflect.TypeOf((*secretField)(nil)).Elem()
+
+func (s *Secret[T]) secretType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (s *Secret[T]) setSecret(v reflect.Value) {
+	s.value = v.Interface().(T)
+}
+
+// isSecret checks for Secret[T] fields
+func isSecret(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(secretFieldType)
+}
+
+// secretBinder binds the value of a Secret[T] field with the binder of T, nil if T isn't supported
+func secretBinder(t reflect.Type, source BindSource) fieldBinder {
+	inner := reflect.New(t).Interface().(secretField).secretType()
+	bind := scalarBinder(inner, source)
+	if bind == nil {
+		return nil
+	}
+	return func(ctx Context, field reflect.Value) error {
+		value := reflect.New(inner).Elem()
+		if err := bind(ctx, value); err != nil {
+			return err
+		}
+		field.Addr().Interface().(secretField).setSecret(value)
+		return nil
+	}
+}
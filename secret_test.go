@@ -0,0 +1,75 @@
+package cuttle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stdout)
+
+	r := New()
+	var got struct {
+		Token  Secret[string]
+		Pin    Secret[uint]
+		APIKey string
+	}
+	r.GET("/secret", func(p struct {
+		Token  Secret[string] `bind:"header" as:"X-Token,required"`
+		Pin    Secret[uint]
+		APIKey string `bind:"header" as:"X-Api-Key,secret"`
+		Ctx    Context
+	}) error {
+		got.Token, got.Pin, got.APIKey = p.Token, p.Pin, p.APIKey
+		return p.Ctx.JSON(http.StatusOK, p)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/secret?Pin=1234", nil)
+	request.Header.Set("X-Token", "hunter2")
+	request.Header.Set("X-Api-Key", "swordfish")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, request)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "hunter2", got.Token.Value())
+	assert.Equal(t, uint(1234), got.Pin.Value())
+	assert.Equal(t, "swordfish", got.APIKey)
+	assert.Contains(t, rec.Body.String(), `"Token":"[REDACTED]","Pin":"[REDACTED]"`)
+
+	// validation failures leave out the value
+	request = httptest.NewRequest(http.MethodGet, "/secret?Pin=hunter3", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, request)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `{"field":"Pin","error":"not a number"}`)
+
+	for _, secret := range []string{"hunter2", "hunter3", "1234", "swordfish"} {
+		assert.NotContains(t, logs.String(), secret)
+		assert.NotContains(t, rec.Body.String(), secret)
+	}
+	assert.Contains(t, logs.String(), "Setting struct value Token [REDACTED]")
+}
+
+func TestSecret_Format(t *testing.T) {
+	s := struct {
+		Token Secret[string] `json:"token"`
+	}{NewSecret("hunter2")}
+	assert.Equal(t, "{[REDACTED]}", fmt.Sprintf("%v", s))
+	assert.Equal(t, "{Token:[REDACTED]}", fmt.Sprintf("%+v", s))
+	assert.NotContains(t, fmt.Sprintf("%#v", s), "hunter2")
+
+	b, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"token":"[REDACTED]"}`, string(b))
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"token":"swordfish"}`), &s))
+	assert.Equal(t, "swordfish", s.Token.Value())
+}
@@ -0,0 +1,74 @@
+package cuttle
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Sort is the order of a list endpoint, read from the sort query param > sort=-created_at,name.
+// The sort tag lists the fields that can be sorted by, a leading - sorts descending.
+//
+//	Sort cuttle.Sort `sort:"created_at,name"`
+//	Sort cuttle.Sort `sort:"created_at,name" as:"order"`
+type Sort []SortField
+
+// SortField is a field of the sort param
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+func (f SortField) String() string {
+	if f.Desc {
+		return "-" + f.Field
+	}
+	return f.Field
+}
+
+var sortType = reflect.TypeOf(Sort(nil))
+
+// listParamName is the query param of sort and filter fields, named by the as tag or the given default
+func listParamName(structTag reflect.StructTag, name string) string {
+	if as, ok := structTag.Lookup("as"); ok {
+		if n := strings.Split(as, ",")[0]; n != "" {
+			return n
+		}
+	}
+	return name
+}
+
+// parseSortTag returns the allowed fields of the sort tag
+func parseSortTag(tag string) (map[string]bool, error) {
+	if tag == "" {
+		return nil, fmt.Errorf("no sort tag lists the fields to sort by")
+	}
+	allowed := map[string]bool{}
+	for _, field := range strings.Split(tag, ",") {
+		allowed[strings.TrimSpace(field)] = true
+	}
+	return allowed, nil
+}
+
+func sortBinder(name string, allowed map[string]bool) fieldBinder {
+	return func(ctx Context, field reflect.Value) error {
+		param := ctx.QueryParam(name)
+		if param == "" {
+			field.Set(reflect.Zero(sortType))
+			return nil
+		}
+		var sort Sort
+		for _, key := range strings.Split(param, ",") {
+			f := SortField{Field: strings.TrimSpace(key)}
+			if strings.HasPrefix(f.Field, "-") {
+				f.Field, f.Desc = f.Field[1:], true
+			}
+			if !allowed[f.Field] {
+				return fmt.Errorf("can't sort by '%v'", f.Field)
+			}
+			sort = append(sort, f)
+		}
+		field.Set(reflect.ValueOf(sort))
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package cuttle
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSort(t *testing.T) {
+	r := New()
+	var got struct {
+		Sort  Sort
+		Order Sort
+	}
+	r.GET("/users", func(p struct {
+		Sort  Sort `sort:"created_at,name"`
+		Order Sort `sort:"id" as:"order"`
+	}) error {
+		got.Sort, got.Order = p.Sort, p.Order
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?sort=-created_at,name&order=-id", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, Sort{{"created_at", true}, {"name", false}}, got.Sort)
+	assert.Equal(t, Sort{{"id", true}}, got.Order)
+	assert.Equal(t, "-created_at", got.Sort[0].String())
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, got.Sort)
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?sort=password", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `{"field":"Sort","error":"can't sort by 'password'"}`)
+}
+
+func TestSort_NoTag(t *testing.T) {
+	r := New()
+	r.CollectErrors = true
+	r.GET("/users", func(p struct {
+		Sort Sort
+	}) error {
+		return nil
+	})
+	assert.Contains(t, r.Check().Error(), "no sort tag lists the fields to sort by")
+}
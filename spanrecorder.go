@@ -0,0 +1,89 @@
+package cuttle
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+// SpanRecorder is an in memory Tracer, it keeps the ended spans so tests can assert them without a collector
+//
+//	spans := cuttle.NewSpanRecorder()
+//	r.Tracer = spans
+//	...
+//	assert.Equal(t, "cuttle.bind", spans.Ended()[0].Name)
+type SpanRecorder struct {
+	mu    sync.Mutex
+	ended []RecordedSpan
+}
+
+// RecordedSpan is a span ended by a SpanRecorder
+type RecordedSpan struct {
+	Name        string
+	SpanContext SpanContext
+	// Parent is the zero SpanContext for root spans
+	Parent     SpanContext
+	Attributes map[string]interface{}
+	Err        error
+	Start, End time.Time
+}
+
+func NewSpanRecorder() *SpanRecorder {
+	return &SpanRecorder{}
+}
+
+func (s *SpanRecorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := SpanContextFromContext(ctx)
+	sc := SpanContext{TraceID: parent.TraceID, Flags: 1}
+	if parent.IsValid() {
+		sc.Flags = parent.Flags
+	} else {
+		parent = SpanContext{}
+		rand.Read(sc.TraceID[:])
+	}
+	rand.Read(sc.SpanID[:])
+
+	span := &recordingSpan{recorder: s, span: RecordedSpan{
+		Name:        name,
+		SpanContext: sc,
+		Parent:      parent,
+		Attributes:  map[string]interface{}{},
+		Start:       time.Now(),
+	}}
+	return ContextWithSpanContext(ctx, sc), span
+}
+
+// Ended returns the ended spans in the order they ended
+func (s *SpanRecorder) Ended() []RecordedSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]RecordedSpan(nil), s.ended...)
+}
+
+// Reset drops the ended spans
+func (s *SpanRecorder) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ended = nil
+}
+
+type recordingSpan struct {
+	recorder *SpanRecorder
+	span     RecordedSpan
+}
+
+func (s *recordingSpan) SetAttribute(key string, value interface{}) {
+	s.span.Attributes[key] = value
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	s.span.Err = err
+}
+
+func (s *recordingSpan) End() {
+	s.span.End = time.Now()
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.recorder.ended = append(s.recorder.ended, s.span)
+}
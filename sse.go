@@ -0,0 +1,270 @@
+package cuttle
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HeaderLastEventID is sent by reconnecting EventSource clients with the id of the last event they got
+const HeaderLastEventID = "Last-Event-ID"
+
+// interval of the heartbeats of event streams when SSEHeartbeat isn't set
+const defaultSSEHeartbeat = 15 * time.Second
+
+var (
+	eventChanType   = reflect.TypeOf((<-chan Event)(nil))
+	sseWriterType   = reflect.TypeOf(SSEWriter{})
+	lastEventIDType = reflect.TypeOf(LastEventID(""))
+)
+
+var errEventNewline = errors.New("event ids and names can't have newlines")
+
+// Event is a server-sent event, Data is written as json.
+// Handlers returning (<-chan Event, error) have their events written until the channel is closed or the
+// client disconnects, the handler's goroutine should stop once ctx.Request().Context() is done.
+//
+//	r.GET("/feed", func(p struct{ Since cuttle.LastEventID }, ctx cuttle.Context) (<-chan cuttle.Event, error) {
+//	    events := make(chan cuttle.Event)
+//	    go func() {
+//	        defer close(events)
+//	        for update := range feed.Since(ctx.Request().Context(), string(p.Since)) {
+//	            events <- cuttle.Event{ID: update.ID, Event: "update", Data: update}
+//	        }
+//	    }()
+//	    return events, nil
+//	})
+type Event struct {
+	ID    string
+	Event string
+	Data  interface{}
+	// Retry tells the client how long to wait before reconnecting, it's left out when 0
+	Retry time.Duration
+}
+
+// LastEventID is the id of the last event a reconnecting client got, read from the Last-Event-ID header.
+// Fields of this type are bound without a bind tag.
+//
+//	Since cuttle.LastEventID
+type LastEventID string
+
+func bindLastEventID(ctx Context, field reflect.Value) error {
+	field.SetString(ctx.Request().Header.Get(HeaderLastEventID))
+	return nil
+}
+
+// SSEWriter writes server-sent events for handlers that send them themselves, the stream starts with the first
+// event so the handler can still fail with an error response before it. Heartbeats are sent until the handler
+// returns.
+//
+//	r.GET("/feed", func(p struct{}, events cuttle.SSEWriter) error {
+//	    for {
+//	        select {
+//	        case <-events.Done():
+//	            return nil
+//	        case update := <-updates:
+//	            if err := events.Send(cuttle.Event{Data: update}); err != nil {
+//	                return err
+//	            }
+//	        }
+//	    }
+//	})
+type SSEWriter struct {
+	state *sseStream
+}
+
+// Send writes an event, it fails once the client is gone
+func (w SSEWriter) Send(event Event) error {
+	s := w.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("the event stream is closed")
+	}
+	if err := s.ctx.Request().Context().Err(); err != nil {
+		return err
+	}
+	if !s.started {
+		s.start()
+		go s.heartbeats()
+	}
+	return s.send(event)
+}
+
+// Done is closed when the client disconnects
+func (w SSEWriter) Done() <-chan struct{} {
+	return w.state.ctx.Request().Context().Done()
+}
+
+// sseStream writes the event stream of a response
+type sseStream struct {
+	ctx       Context
+	heartbeat time.Duration
+	// guards writes of SSEWriter streams, their heartbeats are written from another goroutine
+	mu      sync.Mutex
+	started bool
+	closed  bool
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func (r *Cuttle) newSSEStream(ctx Context) *sseStream {
+	heartbeat := r.SSEHeartbeat
+	if heartbeat == 0 {
+		heartbeat = defaultSSEHeartbeat
+	}
+	return &sseStream{ctx: ctx, heartbeat: heartbeat, stop: make(chan struct{}), stopped: make(chan struct{})}
+}
+
+// sseWriterResolver passes an SSEWriter to the handler, its heartbeats stop when the handler returns
+func (r *Cuttle) sseWriterResolver() argPlan {
+	return argPlan{
+		resolve: func(ctx Context) (reflect.Value, bool, error) {
+			return reflect.ValueOf(SSEWriter{state: r.newSSEStream(ctx)}), true, nil
+		},
+		release: func(v reflect.Value) {
+			v.Interface().(SSEWriter).state.close()
+		},
+	}
+}
+
+// streamEvents writes the events of a handler until the channel is closed or the client disconnects,
+// a nil channel writes nothing and leaves the response to the handler
+func (r *Cuttle) streamEvents(ctx Context, events <-chan Event) error {
+	if events == nil {
+		return nil
+	}
+	s := r.newSSEStream(ctx)
+	s.start()
+	var ticks <-chan time.Time
+	if s.heartbeat > 0 {
+		ticker := time.NewTicker(s.heartbeat)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	done := ctx.Request().Context().Done()
+	for {
+		var err error
+		select {
+		case <-done:
+			return nil
+		case <-ticks:
+			err = s.comment("heartbeat")
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			err = s.send(event)
+		}
+		if err != nil {
+			if ctx.Request().Context().Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// start writes the headers of the stream
+func (s *sseStream) start() {
+	s.started = true
+	header := s.ctx.Response().Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// proxies like nginx would buffer the events otherwise
+	header.Set("X-Accel-Buffering", "no")
+	s.ctx.Response().WriteHeader(http.StatusOK)
+	s.flush()
+}
+
+// send writes an event in the text/event-stream framing, multiline data is split over data lines
+func (s *sseStream) send(event Event) error {
+	if strings.ContainsAny(event.ID, "\r\n") || strings.ContainsAny(event.Event, "\r\n") {
+		return errEventNewline
+	}
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if event.ID != "" {
+		b.WriteString("id: " + event.ID + "\n")
+	}
+	if event.Event != "" {
+		b.WriteString("event: " + event.Event + "\n")
+	}
+	if event.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		b.WriteString("data: " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// comment writes a line ignored by clients, used as heartbeat
+func (s *sseStream) comment(text string) error {
+	return s.write(": " + text + "\n\n")
+}
+
+func (s *sseStream) write(text string) error {
+	if _, err := io.WriteString(s.ctx.Response(), text); err != nil {
+		return err
+	}
+	s.flush()
+	return nil
+}
+
+func (s *sseStream) flush() {
+	if flusher, ok := s.ctx.Response().Writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// heartbeats writes comments to the stream of an SSEWriter until it's closed or the client disconnects
+func (s *sseStream) heartbeats() {
+	defer close(s.stopped)
+	if s.heartbeat <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.heartbeat)
+	defer ticker.Stop()
+	done := s.ctx.Request().Context().Done()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			err := s.comment("heartbeat")
+			s.mu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// close stops the heartbeats once the handler returned, nothing is written to the response after
+func (s *sseStream) close() {
+	s.mu.Lock()
+	s.closed = true
+	started := s.started
+	s.mu.Unlock()
+	if started {
+		close(s.stop)
+		<-s.stopped
+	}
+}
@@ -0,0 +1,124 @@
+package cuttle
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSE_Channel(t *testing.T) {
+	r := New()
+	var since LastEventID
+	r.GET("/feed/:topic", func(p struct {
+		Topic string `bind:"param"`
+		Since LastEventID
+	}, ctx Context) (<-chan Event, error) {
+		if p.Topic != "news" {
+			return nil, echo.NewHTTPError(http.StatusNotFound, "no topic")
+		}
+		since = p.Since
+		events := make(chan Event, 3)
+		events <- Event{ID: "1", Event: "update", Data: map[string]string{"title": "hi"}}
+		events <- Event{Data: "line\nbreak", Retry: 3 * time.Second}
+		events <- Event{Data: []byte("raw")}
+		close(events)
+		return events, nil
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/feed/news", nil)
+	request.Header.Set("Last-Event-ID", "41")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, request)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, LastEventID("41"), since)
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, "id: 1\nevent: update\ndata: {\"title\":\"hi\"}\n\n"+
+		"retry: 3000\ndata: \"line\\nbreak\"\n\n"+
+		"data: \"cmF3\"\n\n", rec.Body.String())
+
+	// the handler can still fail before the stream starts
+	request = httptest.NewRequest(http.MethodGet, "/feed/sports", nil)
+	request.Header.Set(HeaderRequestID, "sse")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, request)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, `{"message":"no topic","request_id":"sse"}`+"\n", rec.Body.String())
+}
+
+func TestSSE_Disconnect(t *testing.T) {
+	r := New()
+	r.SSEHeartbeat = 10 * time.Millisecond
+	stopped := make(chan struct{})
+	r.GET("/feed", func(ctx Context, p struct{}) (<-chan Event, error) {
+		events := make(chan Event)
+		go func() {
+			defer close(stopped)
+			<-ctx.Request().Context().Done()
+		}()
+		return events, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed", nil).WithContext(ctx))
+	<-stopped
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Body.String(), ": heartbeat\n\n: heartbeat\n\n"), rec.Body.String())
+}
+
+func TestSSE_Writer(t *testing.T) {
+	r := New()
+	r.SSEHeartbeat = 5 * time.Millisecond
+	r.GET("/feed", func(p struct {
+		Fail bool
+	}, events SSEWriter) error {
+		if p.Fail {
+			return echo.NewHTTPError(http.StatusForbidden)
+		}
+		if err := events.Send(Event{ID: "1", Data: 1}); err != nil {
+			return err
+		}
+		time.Sleep(20 * time.Millisecond)
+		if err := events.Send(Event{ID: "bad\nid"}); !errors.Is(err, errEventNewline) {
+			return err
+		}
+		return events.Send(Event{ID: "2", Data: 2})
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed?fail=false", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.True(t, strings.HasPrefix(body, "id: 1\ndata: 1\n\n: heartbeat\n\n"), body)
+	assert.Contains(t, body, "\n\nid: 2\ndata: 2\n\n")
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed?fail=true", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// the client is already gone, Send fails with the error of the request context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed?fail=false", nil).WithContext(ctx))
+	assert.NotContains(t, rec.Body.String(), "id: 1")
+	assert.NotEqual(t, "text/event-stream", rec.Header().Get("Content-Type"))
+}
+
+func TestSSE_Check(t *testing.T) {
+	r := New()
+	r.CollectErrors = true
+	r.GET("/feed", func(p struct{}) (chan Event, error) {
+		return nil, nil
+	})
+	assert.Contains(t, r.Check().Error(), "should only return error or (<-chan cuttle.Event, error)")
+}
@@ -0,0 +1,155 @@
+package cuttle
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+)
+
+const partStreamKey = "cuttle.partstream"
+
+// maxStreamFormValue is the limit for scalar form values read ahead of the first file part, same as net/http
+const maxStreamFormValue = 10 << 20
+
+var partStreamType = reflect.TypeOf(PartStream{})
+
+// PartStream reads the file parts of a multipart request as they arrive over Request().MultipartReader(),
+// nothing is buffered to memory or disk. Scalar form fields sent before the first file are still bound.
+//
+//	r.POST("/upload", func(params struct {
+//	    Title  string `bind:"form"`
+//	    Upload cuttle.PartStream
+//	}) error {
+//	    return params.Upload.Each(func(part *multipart.Part) error { ... })
+//	})
+type PartStream struct {
+	state *partStream
+}
+
+type partStream struct {
+	reader *multipart.Reader
+	// first file part, already read while collecting the scalar form fields
+	next *multipart.Part
+	err  error
+}
+
+// Next returns the next file part, io.EOF is returned once there are no more parts
+func (s PartStream) Next() (*multipart.Part, error) {
+	if s.state == nil {
+		return nil, io.EOF
+	}
+	if s.state.err != nil {
+		return nil, s.state.err
+	}
+	if s.state.next != nil {
+		part := s.state.next
+		s.state.next = nil
+		return part, nil
+	}
+	return s.state.reader.NextPart()
+}
+
+// Each calls fn for every remaining part until the stream ends or fn returns an error
+func (s PartStream) Each(fn func(part *multipart.Part) error) error {
+	for {
+		part, err := s.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = fn(part)
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// beginPartStream reads the multipart body up to the first file part, the scalar values read are added to the
+// request's form so the form resolver can bind them
+func beginPartStream(ctx Context) *partStream {
+	if state, ok := ctx.Get(partStreamKey).(*partStream); ok {
+		return state
+	}
+	state := &partStream{}
+	ctx.Set(partStreamKey, state)
+
+	req := ctx.Request()
+	if err := req.ParseForm(); err != nil {
+		state.err = err
+		return state
+	}
+	reader, err := req.MultipartReader()
+	if err != nil {
+		state.err = err
+		return state
+	}
+	state.reader = reader
+	if req.PostForm == nil {
+		req.PostForm = url.Values{}
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return state
+		}
+		if err != nil {
+			state.err = err
+			return state
+		}
+		if part.FileName() != "" {
+			state.next = part
+			return state
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, maxStreamFormValue+1))
+		part.Close()
+		if err != nil {
+			state.err = err
+			return state
+		}
+		if len(value) > maxStreamFormValue {
+			state.err = fmt.Errorf("form value '%v' is too large", part.FormName())
+			return state
+		}
+		req.Form.Add(part.FormName(), string(value))
+		req.PostForm.Add(part.FormName(), string(value))
+	}
+}
+
+func partStreamResolver(ctx Context) (interface{}, error) {
+	state := beginPartStream(ctx)
+	if state.err != nil {
+		return nil, state.err
+	}
+	return PartStream{state: state}, nil
+}
+
+// containsField reports whether t or any of its nested structs has a field matching the type check
+func containsField(t reflect.Type, match func(reflect.Type) bool) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if match(field.Type) {
+			return true
+		}
+		if field.Type.Kind() == reflect.Struct && !isFileType(field.Type) && containsField(field.Type, match) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPartStream(t reflect.Type) bool {
+	return t == partStreamType
+}
@@ -0,0 +1,56 @@
+package cuttle
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_PartStream(t *testing.T) {
+	r := New()
+	r.POST("/upload", func(params struct {
+		Title  string `bind:"form" as:"title"`
+		Upload PartStream
+		Ctx    Context `json:"-"`
+	}) error {
+		assert.Equal(t, "holiday", params.Title)
+		assert.Error(t, params.Ctx.Request().ParseMultipartForm(1<<20), "form should be read through MultipartReader")
+
+		var names []string
+		err := params.Upload.Each(func(part *multipart.Part) error {
+			content, err := io.ReadAll(part)
+			if err != nil {
+				return err
+			}
+			assert.Equal(t, "frame of "+part.FileName(), string(content))
+			names = append(names, part.FileName())
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a.mp4", "b.mp4"}, names)
+		return params.Ctx.NoContent(200)
+	})
+
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	assert.NoError(t, w.WriteField("title", "holiday"))
+	for _, name := range []string{"a.mp4", "b.mp4"} {
+		fw, err := w.CreateFormFile("video", name)
+		assert.NoError(t, err)
+		_, err = fw.Write([]byte("frame of " + name))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+
+	req, err := http.NewRequest("POST", "http://localhost/upload", &b)
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+}
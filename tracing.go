@@ -0,0 +1,171 @@
+package cuttle
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"strings"
+)
+
+// HeaderTraceParent is the W3C trace context header
+const HeaderTraceParent = "traceparent"
+
+// Tracer starts the spans cuttle wraps the binding and the handler call in. Adapt an OpenTelemetry tracer to it,
+// or use a SpanRecorder in tests.
+//
+//	r.Tracer = cuttle.NewSpanRecorder()
+type Tracer interface {
+	// Start starts a span named name, its parent is the SpanContextFromContext of ctx
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a started span, ended once the binding or the handler returned
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// SpanContext identifies a span, it's what the traceparent header carries
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+	// Remote is set when the span context was read from a request
+	Remote bool
+}
+
+func (s SpanContext) IsValid() bool {
+	return s.TraceID != [16]byte{} && s.SpanID != [8]byte{}
+}
+
+func (s SpanContext) Sampled() bool {
+	return s.Flags&1 == 1
+}
+
+// String formats the span context as a traceparent header > 00-<trace id>-<span id>-<flags>
+func (s SpanContext) String() string {
+	return "00-" + hex.EncodeToString(s.TraceID[:]) + "-" + hex.EncodeToString(s.SpanID[:]) + "-" + hex.EncodeToString([]byte{s.Flags})
+}
+
+var errInvalidTraceParent = errors.New("invalid traceparent")
+
+// ParseTraceParent parses a W3C traceparent header, later versions are read as version 00
+func ParseTraceParent(header string) (SpanContext, error) {
+	header = strings.TrimSpace(header)
+	parts := strings.Split(header, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, errInvalidTraceParent
+	}
+	// version 00 has exactly 4 fields and ff is forbidden
+	if (parts[0] == "00" && len(parts) != 4) || parts[0] == "ff" {
+		return SpanContext{}, errInvalidTraceParent
+	}
+	if header != strings.ToLower(header) {
+		return SpanContext{}, errInvalidTraceParent
+	}
+
+	var sc SpanContext
+	var flags [1]byte
+	for _, field := range []struct {
+		dst []byte
+		src string
+	}{{[]byte{0}, parts[0]}, {sc.TraceID[:], parts[1]}, {sc.SpanID[:], parts[2]}, {flags[:], parts[3]}} {
+		if _, err := hex.Decode(field.dst, []byte(field.src)); err != nil {
+			return SpanContext{}, errInvalidTraceParent
+		}
+	}
+	sc.Flags = flags[0]
+	sc.Remote = true
+	if !sc.IsValid() {
+		return SpanContext{}, errInvalidTraceParent
+	}
+	return sc, nil
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a copy of ctx carrying sc, tracers read it as the parent of new spans
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context carried by ctx.
+// In handlers it's the traceparent of the request, or the handler's span with a SpanRecorder.
+//
+//	sc, ok := cuttle.SpanContextFromContext(p.Ctx.Request().Context())
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// extractTraceParent adds the traceparent of the request to its context, invalid headers are ignored
+func extractTraceParent(ctx echo.Context) {
+	header := ctx.Request().Header.Get(HeaderTraceParent)
+	if header == "" {
+		return
+	}
+	sc, err := ParseTraceParent(header)
+	if err != nil {
+		return
+	}
+	req := ctx.Request()
+	ctx.SetRequest(req.WithContext(ContextWithSpanContext(req.Context(), sc)))
+}
+
+// startSpan starts a span as a child of parent, the request carries the span's context until it's swapped back
+func (r *Cuttle) startSpan(ctx echo.Context, parent context.Context, name string, entry *requestLog) Span {
+	spanCtx, span := r.Tracer.Start(parent, name)
+	ctx.SetRequest(ctx.Request().WithContext(spanCtx))
+	span.SetAttribute("http.request.method", entry.method)
+	span.SetAttribute("http.route", entry.route)
+	return span
+}
+
+// endBindSpan records the params and the validation outcome of the binding
+func endBindSpan(span Span, plan *routePlan, ctx Context, err error) {
+	span.SetAttribute("cuttle.params", plan.paramNames())
+	if failures, ok := ctx.Get(failuresKey).([]ValidationFail); ok {
+		fields := make([]string, len(failures))
+		for i, failure := range failures {
+			fields[i] = failure.Field
+		}
+		span.SetAttribute("cuttle.validation", "failed")
+		span.SetAttribute("cuttle.validation.failures", fields)
+	} else if err == nil {
+		span.SetAttribute("cuttle.validation", "ok")
+	}
+	endSpan(span, err)
+}
+
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// paramNames returns the names of the bound fields of the handler's param structs
+func (p *routePlan) paramNames() []string {
+	names := []string{}
+	for _, arg := range p.args {
+		if arg.params != nil {
+			names = arg.params.fieldNames("", names)
+		}
+	}
+	return names
+}
+
+func (p *structPlan) fieldNames(prefix string, names []string) []string {
+	for i := range p.fields {
+		f := &p.fields[i]
+		switch {
+		case f.nested != nil:
+			names = f.nested.fieldNames(prefix+f.name, names)
+		case f.logged:
+			names = append(names, prefix+f.name)
+		}
+	}
+	return names
+}
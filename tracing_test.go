@@ -0,0 +1,127 @@
+package cuttle
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracer(t *testing.T) {
+	spans := NewSpanRecorder()
+	r := New()
+	r.Tracer = spans
+
+	var handlerSpan SpanContext
+	r.GET("/users/:id", func(p struct {
+		ID    uint   `bind:"param"`
+		Limit int    `as:"limit,required"`
+		Token string `bind:"header" as:"X-Token,secret"`
+		Ctx   Context
+	}) error {
+		handlerSpan, _ = SpanContextFromContext(p.Ctx.Request().Context())
+		if p.ID == 0 {
+			return errors.New("no user")
+		}
+		return p.Ctx.NoContent(http.StatusNoContent)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/users/1?limit=10", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, request)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	ended := spans.Ended()
+	if assert.Len(t, ended, 2) {
+		bind, handler := ended[0], ended[1]
+		assert.Equal(t, "cuttle.bind", bind.Name)
+		assert.Equal(t, "cuttle.handler", handler.Name)
+		for _, span := range ended {
+			assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", span.Parent.String())
+			assert.True(t, span.Parent.Remote)
+			assert.Equal(t, span.Parent.TraceID, span.SpanContext.TraceID)
+			assert.Equal(t, "/users/:id", span.Attributes["http.route"])
+			assert.Equal(t, "GET", span.Attributes["http.request.method"])
+			assert.NoError(t, span.Err)
+		}
+		assert.Equal(t, []string{"ID", "Limit", "Token"}, bind.Attributes["cuttle.params"])
+		assert.Equal(t, "ok", bind.Attributes["cuttle.validation"])
+		// the handler sees its own span
+		assert.Equal(t, handler.SpanContext, handlerSpan)
+	}
+
+	// failed validation
+	spans.Reset()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	ended = spans.Ended()
+	if assert.Len(t, ended, 1) {
+		assert.Equal(t, "failed", ended[0].Attributes["cuttle.validation"])
+		assert.Equal(t, []string{"Limit"}, ended[0].Attributes["cuttle.validation.failures"])
+		assert.Error(t, ended[0].Err)
+		// no traceparent starts a new trace
+		assert.False(t, ended[0].Parent.IsValid())
+	}
+
+	// handler error
+	spans.Reset()
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/0?limit=1", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	ended = spans.Ended()
+	if assert.Len(t, ended, 2) {
+		assert.EqualError(t, ended[1].Err, "no user")
+	}
+}
+
+func TestTracer_Unauthorized(t *testing.T) {
+	spans := NewSpanRecorder()
+	r := New()
+	r.Tracer = spans
+	r.Authenticator = &BasicAuthenticator{Validate: func(ctx Context, user, password string) (bool, error) {
+		return false, nil
+	}}
+	r.GET("/me", func(p struct {
+		User Principal
+	}) error {
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/me", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	ended := spans.Ended()
+	if assert.Len(t, ended, 1) {
+		var httpErr *echo.HTTPError
+		assert.ErrorAs(t, ended[0].Err, &httpErr)
+		assert.NotContains(t, ended[0].Attributes, "cuttle.validation")
+	}
+}
+
+func TestParseTraceParent(t *testing.T) {
+	sc, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.NoError(t, err)
+	assert.True(t, sc.Sampled())
+	assert.True(t, sc.Remote)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.String())
+
+	// later versions may add fields
+	_, err = ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra")
+	assert.NoError(t, err)
+
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+	} {
+		_, err := ParseTraceParent(header)
+		assert.Error(t, err, header)
+	}
+}
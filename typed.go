@@ -0,0 +1,43 @@
+package cuttle
+
+import (
+	"net/http"
+)
+
+// Handle registers a typed handler, the signature is checked by the compiler instead of on registration.
+// P is bound like any other param struct, R is written with the encoder the Accept header prefers, see Respond,
+// unless the handler already wrote a response.
+//
+//	cuttle.Get(r, "/users/:id", func(ctx cuttle.Context, p GetUser) (User, error) { ... })
+func Handle[P, R any](r *Cuttle, method, path string, fn func(ctx Context, p P) (R, error), middleware ...MiddlewareFunc) {
+	r.Method(method, path, func(ctx Context, p P) error {
+		res, err := fn(ctx, p)
+		if err != nil {
+			return err
+		}
+		if ctx.Response().Committed {
+			return nil
+		}
+		return r.Respond(ctx, http.StatusOK, res)
+	}, middleware...)
+}
+
+func Get[P, R any](r *Cuttle, path string, fn func(ctx Context, p P) (R, error), middleware ...MiddlewareFunc) {
+	Handle(r, http.MethodGet, path, fn, middleware...)
+}
+
+func Post[P, R any](r *Cuttle, path string, fn func(ctx Context, p P) (R, error), middleware ...MiddlewareFunc) {
+	Handle(r, http.MethodPost, path, fn, middleware...)
+}
+
+func Put[P, R any](r *Cuttle, path string, fn func(ctx Context, p P) (R, error), middleware ...MiddlewareFunc) {
+	Handle(r, http.MethodPut, path, fn, middleware...)
+}
+
+func Patch[P, R any](r *Cuttle, path string, fn func(ctx Context, p P) (R, error), middleware ...MiddlewareFunc) {
+	Handle(r, http.MethodPatch, path, fn, middleware...)
+}
+
+func Delete[P, R any](r *Cuttle, path string, fn func(ctx Context, p P) (R, error), middleware ...MiddlewareFunc) {
+	Handle(r, http.MethodDelete, path, fn, middleware...)
+}
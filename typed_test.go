@@ -0,0 +1,58 @@
+package cuttle
+
+import (
+	"bytes"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type typedUser struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestHandle_Typed(t *testing.T) {
+	r := New()
+	Get(r, "/users/:id", func(ctx Context, p struct {
+		ID uint `bind:"param"`
+	}) (typedUser, error) {
+		if p.ID == 0 {
+			return typedUser{}, echo.NewHTTPError(http.StatusNotFound, "no user")
+		}
+		return typedUser{ID: p.ID, Name: "joe"}, nil
+	})
+	Post(r, "/users", func(ctx Context, p *struct {
+		FromJson
+		Name string `json:"name"`
+	}) (*typedUser, error) {
+		return &typedUser{ID: 2, Name: p.Name}, nil
+	})
+	Delete(r, "/users/:id", func(ctx Context, p struct{}) (struct{}, error) {
+		return struct{}{}, ctx.NoContent(http.StatusNoContent)
+	})
+
+	for _, tc := range []struct {
+		method, path, body string
+		code               int
+		expect             string
+	}{
+		{"GET", "/users/1", "", 200, `{"id":1,"name":"joe"}`},
+		{"GET", "/users/0", "", 404, `{"message":"no user","request_id":"typed"}`},
+		{"GET", "/users/abc", "", 404, ""},
+		{"POST", "/users", `{"name":"mama"}`, 200, `{"id":2,"name":"mama"}`},
+		{"DELETE", "/users/1", "", 204, ""},
+	} {
+		request, err := http.NewRequest(tc.method, "http://localhost"+tc.path, bytes.NewBufferString(tc.body))
+		assert.NoError(t, err)
+		request.Header.Set(HeaderRequestID, "typed")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, request)
+		assert.Equal(t, tc.code, w.Code, tc.path)
+		if tc.expect != "" {
+			assert.JSONEq(t, tc.expect, w.Body.String(), tc.path)
+		}
+	}
+}